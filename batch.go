@@ -0,0 +1,180 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Statement is a SQL statement with its arguments.
+type Statement struct {
+	SQL  string
+	Args []interface{}
+}
+
+// BatchOptions controls how InsertBatch splits rows into statements.
+type BatchOptions struct {
+	// MaxPlaceholders overrides the parameter limit of dialect, such as 999
+	// for SQLite before 3.32.0.
+	MaxPlaceholders int
+	// MaxPacketSize overrides the statement size limit of dialect, such as
+	// the max_allowed_packet of MySQL server.
+	MaxPacketSize int
+	// Tx executes all statements in one transaction if the executor can
+	// begin one.
+	Tx bool
+}
+
+// argSize estimates bytes of argument sent to the server.
+func argSize(v interface{}) int {
+	switch v := v.(type) {
+	case string:
+		return len(v) + 8
+	case []byte:
+		return len(v) + 8
+	}
+	return 16
+}
+
+// InsertBatch returns multi-row INSERT statements of models, rows are split
+// so that no statement exceeds the parameter and size limits of dialect.
+// Created and updated columns are filled from SQLBuilder.Clock or
+// CURRENT_TIMESTAMP, models are not changed.
+func InsertBatch[T any](b *SQLBuilder, models []T, opts BatchOptions) ([]Statement, error) {
+	if len(models) == 0 {
+		return nil, nil
+	}
+	table, err := b.SQLUtil.parser.StructTable(models[0])
+	if err != nil {
+		return nil, err
+	}
+	features := dialectFeatures(b.SQLUtil.dialect)
+	maxParams, maxSize := features.MaxPlaceholders, features.MaxPacketSize
+	if opts.MaxPlaceholders > 0 {
+		maxParams = opts.MaxPlaceholders
+	}
+	if opts.MaxPacketSize > 0 {
+		maxSize = opts.MaxPacketSize
+	}
+
+	var (
+		columns   = b.SQLUtil.insertColumns(table, nil)
+		cols      = make([]Column, len(columns))
+		rowParams int
+	)
+	for i, name := range columns {
+		cols[i], _ = table.Col(name)
+		if !cols[i].Created && !cols[i].Updated || b.Clock != nil {
+			rowParams++
+		}
+	}
+	if maxParams > 0 && rowParams > maxParams {
+		return nil, fmt.Errorf("%s: %d parameters of a row exceeds limit %d", table.Name, rowParams, maxParams)
+	}
+	prefix := fmt.Sprintf("INSERT INTO %s(%s) VALUES", table.Name, columns.List())
+
+	var (
+		stmts []Statement
+		buf   strings.Builder
+		args  []interface{}
+		rows  int
+		size  int
+	)
+	flush := func() {
+		if rows > 0 {
+			stmts = append(stmts, Statement{SQL: buf.String(), Args: args})
+		}
+		buf.Reset()
+		buf.WriteString(prefix)
+		args, rows, size = nil, 0, len(prefix)
+	}
+	flush()
+	for n, model := range models {
+		refv := reflect.ValueOf(model)
+		for refv.Kind() == reflect.Ptr {
+			refv = refv.Elem()
+		}
+		if !refv.IsValid() {
+			return nil, fmt.Errorf("%s: model %d is nil", table.Name, n)
+		}
+		if refv.Type() != table.Type {
+			return nil, fmt.Errorf("%s: unexpected model type %s", table.Name, refv.Type())
+		}
+		rowArgs := make([]interface{}, len(cols))
+		rowSize := 2
+		for i, col := range cols {
+			switch {
+			case (col.Created || col.Updated) && b.Clock == nil:
+				rowSize += len("CURRENT_TIMESTAMP, ")
+				continue
+			case col.Created || col.Updated:
+				v := reflect.ValueOf(b.Clock())
+				if col.Field.Type.Kind() == reflect.Ptr {
+					ptr := reflect.New(v.Type())
+					ptr.Elem().Set(v)
+					v = ptr
+				}
+				rowArgs[i] = v.Interface()
+			default:
+				rowArgs[i] = refv.FieldByIndex(col.Field.Index).Interface()
+			}
+			rowSize += argSize(rowArgs[i]) + 8
+		}
+		if maxSize > 0 && len(prefix)+rowSize > maxSize {
+			return nil, fmt.Errorf("%s: row of %d bytes exceeds limit %d", table.Name, rowSize, maxSize)
+		}
+		if rows > 0 && (maxParams > 0 && len(args)+rowParams > maxParams || maxSize > 0 && size+rowSize > maxSize) {
+			flush()
+		}
+
+		if rows > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteByte('(')
+		for i, col := range cols {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			if (col.Created || col.Updated) && b.Clock == nil {
+				buf.WriteString("CURRENT_TIMESTAMP")
+				continue
+			}
+			args = append(args, rowArgs[i])
+			buf.WriteString(b.SQLUtil.placeholder(len(args)))
+		}
+		buf.WriteByte(')')
+		rows++
+		size += rowSize
+	}
+	flush()
+	return stmts, nil
+}
+
+// ExecInsertBatch executes the statements of InsertBatch and returns the
+// number of inserted rows.
+func ExecInsertBatch[T any](ctx context.Context, b *SQLBuilder, ex Executor, models []T, opts BatchOptions) (n int64, err error) {
+	stmts, err := InsertBatch(b, models, opts)
+	if err != nil {
+		return 0, err
+	}
+	if beginner, ok := ex.(TxBeginner); ok && opts.Tx && len(stmts) > 0 {
+		var tx *sql.Tx
+		tx, err = beginner.BeginTx(ctx, nil)
+		if err != nil {
+			return 0, err
+		}
+		defer TxDone(tx, &err)
+		ex = tx
+	}
+	for _, stmt := range stmts {
+		var affected int64
+		affected, err = ResultRowsAffected(ex.ExecContext(ctx, stmt.SQL, stmt.Args...))
+		if err != nil {
+			return n, err
+		}
+		n += affected
+	}
+	return n, nil
+}
@@ -0,0 +1,129 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInsertBatch(t *testing.T) {
+	type Item struct {
+		Id      int64 `sqldb:"pk"`
+		Name    string
+		Total   int       `sqldb:"generated:'id * 2'"`
+		Created time.Time `sqldb:"created"`
+	}
+	items := []Item{{Id: 1, Name: "a"}, {Id: 2, Name: "b"}, {Id: 3, Name: "c"}}
+	p := NewTableParser()
+
+	stmts, err := InsertBatch(NewSQLBuilder(NewSQLUtil(p, Postgres{})), items, BatchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []Statement{{
+		SQL:  "INSERT INTO item(id, name, created) VALUES($1, $2, CURRENT_TIMESTAMP), ($3, $4, CURRENT_TIMESTAMP), ($5, $6, CURRENT_TIMESTAMP)",
+		Args: []interface{}{int64(1), "a", int64(2), "b", int64(3), "c"},
+	}}
+	if !reflect.DeepEqual(stmts, expect) {
+		t.Errorf("expect %v, but got %v", expect, stmts)
+	}
+
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	b := NewSQLBuilder(NewSQLUtil(p, SQLite3{}))
+	b.Clock = func() time.Time { return now }
+	stmts, err = InsertBatch(b, items, BatchOptions{MaxPlaceholders: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect = []Statement{
+		{
+			SQL:  "INSERT INTO item(id, name, created) VALUES(?, ?, ?), (?, ?, ?)",
+			Args: []interface{}{int64(1), "a", now, int64(2), "b", now},
+		},
+		{
+			SQL:  "INSERT INTO item(id, name, created) VALUES(?, ?, ?)",
+			Args: []interface{}{int64(3), "c", now},
+		},
+	}
+	if !reflect.DeepEqual(stmts, expect) {
+		t.Errorf("expect %v, but got %v", expect, stmts)
+	}
+	if _, err = InsertBatch(b, items, BatchOptions{MaxPlaceholders: 2}); err == nil {
+		t.Error("expect error for row exceeding placeholder limit")
+	}
+
+	long := make([]Item, 3)
+	for i := range long {
+		long[i] = Item{Id: int64(i), Name: strings.Repeat("x", 1000)}
+	}
+	stmts, err = InsertBatch(NewSQLBuilder(NewSQLUtil(p, MySQL{})), long, BatchOptions{MaxPacketSize: 2500})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stmts) != 2 || len(stmts[0].Args) != 4 || len(stmts[1].Args) != 2 {
+		t.Errorf("expect statements of 2 and 1 rows, but got %v", stmts)
+	}
+	if _, err = InsertBatch(NewSQLBuilder(NewSQLUtil(p, MySQL{})), long, BatchOptions{MaxPacketSize: 500}); err == nil {
+		t.Error("expect error for row exceeding packet size")
+	}
+
+	ptrs := []*Item{&items[0], &items[1]}
+	stmts, err = InsertBatch(NewSQLBuilder(NewSQLUtil(p, MySQL{})), ptrs, BatchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stmts) != 1 || len(stmts[0].Args) != 4 {
+		t.Errorf("unexpected statements of pointers %v", stmts)
+	}
+	for _, ptrs := range [][]*Item{{&items[0], nil}, {nil, &items[0]}} {
+		if _, err = InsertBatch(NewSQLBuilder(NewSQLUtil(p, MySQL{})), ptrs, BatchOptions{}); err == nil {
+			t.Error("expect error for nil model")
+		}
+	}
+}
+
+func TestExecInsertBatch(t *testing.T) {
+	type Row struct {
+		Id   int64 `sqldb:"pk"`
+		Name string
+	}
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	b := NewSQLBuilder(NewSQLUtil(NewTableParser(), SQLite3{}))
+	if err = b.SQLUtil.CreateTables(db, Row{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	rows := make([]Row, 10)
+	for i := range rows {
+		rows[i] = Row{Id: int64(i + 1), Name: "row"}
+	}
+	n, err := ExecInsertBatch(ctx, b, db, rows, BatchOptions{MaxPlaceholders: 6, Tx: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 10 {
+		t.Errorf("expect 10 inserted rows, but got %d", n)
+	}
+
+	// the duplicate row in the last statement rolls back the others
+	dup := []Row{{Id: 11}, {Id: 12}, {Id: 13}, {Id: 1}}
+	if _, err = ExecInsertBatch(ctx, b, db, dup, BatchOptions{MaxPlaceholders: 6, Tx: true}); err == nil {
+		t.Fatal("expect error for duplicate primary key")
+	}
+	var count int
+	if err = db.QueryRow("SELECT COUNT(*) FROM row").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 10 {
+		t.Errorf("expect 10 rows after rollback, but got %d", count)
+	}
+}
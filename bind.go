@@ -0,0 +1,252 @@
+package sqldb
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ErrTooManyPlaceholders is returned if a statement needs more parameters
+// than the dialect allows.
+var ErrTooManyPlaceholders = errors.New("sqldb: too many placeholders")
+
+// expandable reports whether v is a slice expanded to one placeholder per
+// element, []byte and driver.Valuer are bound as is.
+func expandable(v interface{}) (reflect.Value, bool) {
+	if _, ok := v.(driver.Valuer); ok || v == nil {
+		return reflect.Value{}, false
+	}
+	refv := reflect.ValueOf(v)
+	switch refv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if refv.Type().Elem().Kind() == reflect.Uint8 {
+			return reflect.Value{}, false
+		}
+		return refv, true
+	}
+	return reflect.Value{}, false
+}
+
+// bindQuery replaces parameters outside of quotes, comments, dollar-quoted and
+// E'...' escape strings with placeholders of dialect, they are ? if named is
+// false, otherwise :name. Slice arguments are expanded, empty ones are
+// rejected since no list renders them correctly in both IN and NOT IN, use In
+// instead.
+func (s *SQLUtil) bindQuery(query string, named bool, param func(name string) (interface{}, error)) (string, []interface{}, error) {
+	var (
+		buf   strings.Builder
+		args  []interface{}
+		quote byte
+		// escape reports whether quote is a Postgres E'' string, where
+		// backslash escapes quotes
+		escape bool
+		params int
+	)
+	bind := func(name string) error {
+		params++
+		v, err := param(name)
+		if err != nil {
+			return err
+		}
+		refv, ok := expandable(v)
+		if !ok {
+			args = append(args, v)
+			buf.WriteString(s.placeholder(len(args)))
+			return nil
+		}
+		if refv.Len() == 0 {
+			if named {
+				return fmt.Errorf("parameter :%s: empty slice", name)
+			}
+			return fmt.Errorf("parameter %d: empty slice", params)
+		}
+		for i := 0; i < refv.Len(); i++ {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			args = append(args, refv.Index(i).Interface())
+			buf.WriteString(s.placeholder(len(args)))
+		}
+		return nil
+	}
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if escape && c == '\\' && i+1 < len(query) {
+				buf.WriteString(query[i : i+2])
+				i++
+				continue
+			}
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			escape = c == '\'' && i > 0 && (query[i-1] == 'E' || query[i-1] == 'e') &&
+				(i == 1 || !isParamNameByte(query[i-2]))
+		case c == '-' || c == '/' || c == '$':
+			end := skipLiteral(query, i)
+			if end == i {
+				break
+			}
+			buf.WriteString(query[i:end])
+			i = end - 1
+			continue
+		case !named && c == '?':
+			if err := bind(""); err != nil {
+				return "", nil, err
+			}
+			continue
+		case named && c == ':' && i+1 < len(query) && query[i+1] == ':':
+			// Postgres type cast
+			buf.WriteString("::")
+			i++
+			continue
+		case named && c == ':':
+			end := i + 1
+			for end < len(query) && isParamNameByte(query[end]) {
+				end++
+			}
+			if end == i+1 {
+				break
+			}
+			if err := bind(query[i+1 : end]); err != nil {
+				return "", nil, err
+			}
+			i = end - 1
+			continue
+		}
+		buf.WriteByte(c)
+	}
+	if limit := dialectFeatures(s.dialect).MaxPlaceholders; limit > 0 && len(args) > limit {
+		return "", nil, fmt.Errorf("%w: %d parameters, %d allowed", ErrTooManyPlaceholders, len(args), limit)
+	}
+	return buf.String(), args, nil
+}
+
+// skipLiteral returns the end of the comment or Postgres dollar-quoted string
+// starting at i, or i if there is none. Unterminated ones end with query.
+func skipLiteral(query string, i int) int {
+	rest := query[i:]
+	switch {
+	case strings.HasPrefix(rest, "--"):
+		if end := strings.IndexByte(rest, '\n'); end >= 0 {
+			return i + end + 1
+		}
+		return len(query)
+	case strings.HasPrefix(rest, "/*"):
+		if end := strings.Index(rest[2:], "*/"); end >= 0 {
+			return i + 2 + end + 2
+		}
+		return len(query)
+	case rest[0] == '$' && (i == 0 || !isParamNameByte(query[i-1]) && query[i-1] != '$'):
+		// $tag$ where tag is empty or an identifier, $1 is a placeholder
+		end := 1
+		for end < len(rest) && isParamNameByte(rest[end]) {
+			end++
+		}
+		if end >= len(rest) || rest[end] != '$' || end > 1 && rest[1] >= '0' && rest[1] <= '9' {
+			return i
+		}
+		tag := rest[:end+1]
+		if close := strings.Index(rest[len(tag):], tag); close >= 0 {
+			return i + len(tag) + close + len(tag)
+		}
+		return len(query)
+	}
+	return i
+}
+
+func isParamNameByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// Bind converts ? parameters of query to placeholders of dialect. Slice
+// arguments except []byte are expanded to one placeholder per element, empty
+// ones are rendered as NULL, so that IN (?) matches nothing.
+func (s *SQLUtil) Bind(query string, args ...interface{}) (string, []interface{}, error) {
+	var n int
+	query, bound, err := s.bindQuery(query, false, func(string) (interface{}, error) {
+		if n >= len(args) {
+			return nil, fmt.Errorf("sqldb: missing argument of parameter %d", n+1)
+		}
+		n++
+		return args[n-1], nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	if n != len(args) {
+		return "", nil, fmt.Errorf("sqldb: %d arguments for %d parameters", len(args), n)
+	}
+	return query, bound, nil
+}
+
+// BindNamed is like Bind but converts :name parameters, such as the ones
+// rendered by SQLBuilder. Arguments are read from arg, a map with string keys
+// or a structure whose columns are the names. Created and updated columns of
+// structure must have been filled, see SQLBuilder.Touch.
+func (s *SQLUtil) BindNamed(query string, arg interface{}) (string, []interface{}, error) {
+	return s.bindNamed(query, arg, nil)
+}
+
+// BindNamed is like SQLUtil.BindNamed but binds created and updated columns
+// of structure to the time of Clock if it's not nil.
+func (b *SQLBuilder) BindNamed(query string, arg interface{}) (string, []interface{}, error) {
+	return b.SQLUtil.bindNamed(query, arg, b.Clock)
+}
+
+func (s *SQLUtil) bindNamed(query string, arg interface{}, clock func() time.Time) (string, []interface{}, error) {
+	param, err := s.namedArgs(arg, clock)
+	if err != nil {
+		return "", nil, err
+	}
+	return s.bindQuery(query, true, param)
+}
+
+func (s *SQLUtil) namedArgs(arg interface{}, clock func() time.Time) (func(name string) (interface{}, error), error) {
+	refv := reflect.ValueOf(arg)
+	for refv.Kind() == reflect.Ptr && !refv.IsNil() {
+		refv = refv.Elem()
+	}
+	switch {
+	case refv.Kind() == reflect.Map && refv.Type().Key().Kind() == reflect.String:
+		return func(name string) (interface{}, error) {
+			v := refv.MapIndex(reflect.ValueOf(name).Convert(refv.Type().Key()))
+			if !v.IsValid() {
+				return nil, fmt.Errorf("sqldb: missing argument of parameter %s", name)
+			}
+			return v.Interface(), nil
+		}, nil
+	case refv.Kind() == reflect.Struct:
+		table, err := s.parser.StructTable(refv.Interface())
+		if err != nil {
+			return nil, err
+		}
+		var now time.Time
+		if clock != nil {
+			now = clock()
+		}
+		return func(name string) (interface{}, error) {
+			col, has := table.Col(name)
+			if !has {
+				return nil, fmt.Errorf("%s: missing argument of parameter %s", table.Name, name)
+			}
+			field := refv.FieldByIndex(col.Field.Index)
+			if col.Created || col.Updated {
+				if clock != nil {
+					return now, nil
+				}
+				if field.IsZero() || field.Kind() == reflect.Ptr && field.Elem().IsZero() {
+					return nil, fmt.Errorf("%s: %s is not filled, see SQLBuilder.Touch", table.Name, name)
+				}
+			}
+			return field.Interface(), nil
+		}, nil
+	}
+	return nil, fmt.Errorf("sqldb: named arguments must be map or structure, got %T", arg)
+}
@@ -0,0 +1,147 @@
+package sqldb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestBind(t *testing.T) {
+	type User struct {
+		Id     int64 `sqldb:"pk"`
+		Name   string
+		Avatar []byte
+	}
+	var (
+		p        = NewTableParser()
+		postgres = NewSQLUtil(p, Postgres{})
+		mysql    = NewSQLUtil(p, MySQL{})
+	)
+
+	type testCase struct {
+		SQL   *SQLUtil
+		Query string
+		Args  []interface{}
+		Named interface{}
+
+		Expect     string
+		ExpectArgs []interface{}
+	}
+	cases := []testCase{
+		{
+			SQL:        postgres,
+			Query:      "SELECT id FROM user WHERE id IN (?) AND name = ? AND note = '?'",
+			Args:       []interface{}{[]int64{1, 2, 3}, "bob"},
+			Expect:     "SELECT id FROM user WHERE id IN ($1, $2, $3) AND name = $4 AND note = '?'",
+			ExpectArgs: []interface{}{int64(1), int64(2), int64(3), "bob"},
+		},
+		{
+			SQL:        mysql,
+			Query:      "SELECT id FROM user WHERE id IN (?)",
+			Args:       []interface{}{[]string{"a", "b"}},
+			Expect:     "SELECT id FROM user WHERE id IN (?, ?)",
+			ExpectArgs: []interface{}{"a", "b"},
+		},
+		{
+			SQL:        postgres,
+			Query:      "UPDATE user SET avatar = ? WHERE id = ?",
+			Args:       []interface{}{[]byte("png"), 1},
+			Expect:     "UPDATE user SET avatar = $1 WHERE id = $2",
+			ExpectArgs: []interface{}{[]byte("png"), 1},
+		},
+		{
+			SQL:        postgres,
+			Query:      "SELECT id::text FROM user WHERE id IN (:ids) AND name = :name AND note = ':id'",
+			Named:      map[string]interface{}{"ids": []int{1, 2}, "name": "bob"},
+			Expect:     "SELECT id::text FROM user WHERE id IN ($1, $2) AND name = $3 AND note = ':id'",
+			ExpectArgs: []interface{}{1, 2, "bob"},
+		},
+		{
+			SQL:        mysql,
+			Query:      "UPDATE user SET name = :name, avatar = :avatar WHERE id = :id",
+			Named:      &User{Id: 1, Name: "bob", Avatar: []byte("png")},
+			Expect:     "UPDATE user SET name = ?, avatar = ? WHERE id = ?",
+			ExpectArgs: []interface{}{"bob", []byte("png"), int64(1)},
+		},
+		{
+			SQL:        postgres,
+			Query:      "SELECT id -- who's ?\nFROM user /* :id ? */ WHERE name = :name AND t = 1-:t/2",
+			Named:      map[string]interface{}{"name": "bob", "t": 4},
+			Expect:     "SELECT id -- who's ?\nFROM user /* :id ? */ WHERE name = $1 AND t = 1-$2/2",
+			ExpectArgs: []interface{}{"bob", 4},
+		},
+		{
+			SQL:        postgres,
+			Query:      "SELECT E'\\':x', :x, type = 'a\\', :y",
+			Named:      map[string]interface{}{"x": 1, "y": 2},
+			Expect:     "SELECT E'\\':x', $1, type = 'a\\', $2",
+			ExpectArgs: []interface{}{1, 2},
+		},
+		{
+			SQL:        postgres,
+			Query:      "DO $$ BEGIN RAISE NOTICE 'it''s ?'; END $$; SELECT $fn$ :x ? $$ $fn$, ?",
+			Args:       []interface{}{1},
+			Expect:     "DO $$ BEGIN RAISE NOTICE 'it''s ?'; END $$; SELECT $fn$ :x ? $$ $fn$, $1",
+			ExpectArgs: []interface{}{1},
+		},
+	}
+	for i, c := range cases {
+		var (
+			sql  string
+			args []interface{}
+			err  error
+		)
+		if c.Named != nil {
+			sql, args, err = c.SQL.BindNamed(c.Query, c.Named)
+		} else {
+			sql, args, err = c.SQL.Bind(c.Query, c.Args...)
+		}
+		if err != nil {
+			t.Fatalf("%d: %s", i, err)
+		}
+		if sql != c.Expect {
+			t.Errorf("%d: expect %q, but got %q", i, c.Expect, sql)
+		}
+		if !reflect.DeepEqual(args, c.ExpectArgs) {
+			t.Errorf("%d: expect args %v, but got %v", i, c.ExpectArgs, args)
+		}
+	}
+
+	if _, _, err := postgres.Bind("SELECT ? + ?", 1); err == nil {
+		t.Error("expect error for missing argument")
+	}
+	if _, _, err := postgres.Bind("SELECT ?", 1, 2); err == nil {
+		t.Error("expect error for extra argument")
+	}
+	if _, _, err := postgres.BindNamed("SELECT :missing", User{}); err == nil {
+		t.Error("expect error for missing named argument")
+	}
+	if _, _, err := postgres.BindNamed("SELECT :id", 1); err == nil {
+		t.Error("expect error for invalid named arguments")
+	}
+	// IN (NULL) matches nothing, but so does NOT IN (NULL)
+	if _, _, err := postgres.Bind("SELECT id FROM user WHERE id IN (?)", []int64{}); err == nil {
+		t.Error("expect error for empty slice")
+	}
+	if _, _, err := postgres.BindNamed("SELECT id FROM user WHERE id NOT IN (:ids)", map[string]interface{}{"ids": []int{}}); err == nil {
+		t.Error("expect error for empty slice")
+	}
+	ids := make([]int, 65536)
+	if _, _, err := postgres.Bind("SELECT id FROM user WHERE id IN (?)", ids); !errors.Is(err, ErrTooManyPlaceholders) {
+		t.Errorf("expect ErrTooManyPlaceholders, but got %v", err)
+	}
+	if _, _, err := mysql.Cond(User{}, In("id", ids[:100])); err != nil {
+		t.Error(err)
+	}
+
+	sql, args, err := NewSQLBuilder(postgres).Select(User{}).Columns("id").Where("id IN (?)", []int{1, 2}).WhereExpr(In("name", []string{})).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := "SELECT id FROM user WHERE (id IN ($1, $2)) AND (1 = 0)"; sql != expect {
+		t.Errorf("expect %q, but got %q", expect, sql)
+	}
+	if !reflect.DeepEqual(args, []interface{}{1, 2}) {
+		t.Errorf("unexpected args %v", args)
+	}
+}
@@ -0,0 +1,86 @@
+// Command sqldb-gen generates go-sqldb model structs from an existing
+// database.
+//
+// Usage:
+//
+//	sqldb-gen -dsn file:app.db -pkg models -o models/models.go
+//	sqldb-gen -init schema.sql -tables user,post
+//
+// With -init, the SQL script is executed before inspection, so the default
+// in-memory database turns a schema script into models.
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	sqldb "github.com/cosiner/go-sqldb"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func main() {
+	var (
+		driver = flag.String("driver", "sqlite3", "database driver, only sqlite3 is built in")
+		dsn    = flag.String("dsn", ":memory:", "data source name")
+		script = flag.String("init", "", "SQL script executed before inspection")
+		pkg    = flag.String("pkg", "models", "package name of generated file")
+		tables = flag.String("tables", "", "comma separated tables, all tables if empty")
+		output = flag.String("o", "", "output file, stdout if empty")
+	)
+	flag.Parse()
+
+	err := generate(*driver, *dsn, *script, *pkg, *tables, *output)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "sqldb-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func generate(driver, dsn, script, pkg, tables, output string) error {
+	dialect, err := sqldb.DialectByName(driver)
+	if err != nil {
+		return err
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	// in-memory sqlite3 database is private to each connection
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	if script != "" {
+		content, err := os.ReadFile(script)
+		if err != nil {
+			return err
+		}
+		for _, stmt := range sqldb.SplitSQL(string(content)) {
+			_, err = db.ExecContext(ctx, stmt)
+			if err != nil {
+				return fmt.Errorf("%s: %w", script, err)
+			}
+		}
+	}
+
+	var names []string
+	if tables != "" {
+		names = strings.Split(tables, ",")
+	}
+	su := sqldb.NewSQLUtil(sqldb.NewTableParser(sqldb.TableParserOptions{Default: true}), dialect)
+	var buf bytes.Buffer
+	err = su.GenerateModels(ctx, db, &buf, pkg, names...)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		_, err = os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	return os.WriteFile(output, buf.Bytes(), 0644)
+}
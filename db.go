@@ -2,8 +2,10 @@ package sqldb
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"io"
+	"sort"
 	"time"
 )
 
@@ -20,15 +22,21 @@ type DBConfig struct {
 	Options     map[string]string `json:"options" yaml:"options" toml:"options"`
 }
 
+// JoinOptions joins options sorted by key.
 func (d *DBConfig) JoinOptions(kvSep, optSep string) string {
+	keys := make([]string, 0, len(d.Options))
+	for k := range d.Options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
 	var buf bytes.Buffer
-	for k, v := range d.Options {
+	for _, k := range keys {
 		if buf.Len() > 0 {
 			buf.WriteString(optSep)
 		}
 		buf.WriteString(k)
 		buf.WriteString(kvSep)
-		buf.WriteString(v)
+		buf.WriteString(d.Options[k])
 	}
 	return buf.String()
 }
@@ -95,6 +103,23 @@ func Open(dialect DBDialect, config DBConfig) (*sql.DB, error) {
 	return db, nil
 }
 
+// Queryer is implemented by *sql.DB, *sql.Tx and *sql.Conn.
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Executor is implemented by *sql.DB, *sql.Tx and *sql.Conn.
+type Executor interface {
+	Queryer
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// TxBeginner is implemented by *sql.DB and *sql.Conn.
+type TxBeginner interface {
+	Executor
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
 type Tx interface {
 	Commit() error
 	Rollback() error
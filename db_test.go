@@ -0,0 +1,17 @@
+package sqldb
+
+import "testing"
+
+func TestJoinOptions(t *testing.T) {
+	config := DBConfig{
+		Options: map[string]string{"sslmode": "disable", "connect_timeout": "5", "application_name": "app"},
+	}
+	for i := 0; i < 20; i++ {
+		if s := config.JoinOptions("=", "&"); s != "application_name=app&connect_timeout=5&sslmode=disable" {
+			t.Fatal("options should be sorted by key", s)
+		}
+	}
+	if dsn := (Postgres{}).DSN(config); dsn != "postgres://localhost:5432/?application_name=app&connect_timeout=5&sslmode=disable" {
+		t.Fatal("unexpected dsn", dsn)
+	}
+}
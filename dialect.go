@@ -3,10 +3,145 @@ package sqldb
 import (
 	"fmt"
 	"strings"
+	"time"
 )
 
+// DialectFeatures describes capabilities and limits of a dialect.
+type DialectFeatures struct {
+	// VirtualGenerated reports whether VIRTUAL generated columns are supported,
+	// STORED ones are always supported.
+	VirtualGenerated bool
+	// CreateIndexIfNotExists reports whether CREATE INDEX IF NOT EXISTS is
+	// supported.
+	CreateIndexIfNotExists bool
+	// Alter is the way ALTER TABLE statements are rendered.
+	Alter AlterStyle
+	// TransactionalDDL reports whether DDL statements can be rolled back.
+	TransactionalDDL bool
+	// Placeholder is the style of positional parameters.
+	Placeholder PlaceholderStyle
+	// ForwardForeignKeys reports whether foreign keys may reference tables
+	// not created yet.
+	ForwardForeignKeys bool
+	// DropCascade reports whether DROP TABLE supports CASCADE.
+	DropCascade bool
+	// MaxIdentifierLen is the maximum bytes of identifiers, zero means no
+	// limit.
+	MaxIdentifierLen int
+	// TableScopedNames reports whether index, primary key and unique
+	// constraint names are unique per table instead of per schema.
+	TableScopedNames bool
+	// OffsetFetch reports whether rows are limited by OFFSET ... FETCH
+	// instead of LIMIT.
+	OffsetFetch bool
+	// NoLimit is the LIMIT value meaning no limit, it's required before
+	// OFFSET if not empty.
+	NoLimit string
+	// MaxPlaceholders is the maximum parameters of a statement, zero means no
+	// limit.
+	MaxPlaceholders int
+	// MaxPacketSize is the maximum bytes of a statement with its arguments,
+	// zero means no limit.
+	MaxPacketSize int
+	// Upsert is the way conflicting inserts are rendered.
+	Upsert UpsertStyle
+}
+
+type AlterStyle int
+
+const (
+	// AlterStandard uses ALTER COLUMN for type, nullability and default, and
+	// DROP CONSTRAINT for constraints.
+	AlterStandard AlterStyle = iota
+	// AlterModify uses MODIFY COLUMN with the full column definition, and
+	// DROP INDEX/FOREIGN KEY/PRIMARY KEY for constraints.
+	AlterModify
+	// AlterRebuild only supports ADD and DROP COLUMN, other changes rebuild
+	// the table by copying rows into a new one.
+	AlterRebuild
+)
+
+type UpsertStyle int
+
+const (
+	// UpsertOnConflict uses ON CONFLICT (...) DO UPDATE SET col = EXCLUDED.col
+	// or DO NOTHING.
+	UpsertOnConflict UpsertStyle = iota
+	// UpsertOnDuplicateKey uses ON DUPLICATE KEY UPDATE col = VALUES(col), it
+	// applies to conflicts of any unique key.
+	UpsertOnDuplicateKey
+)
+
+type PlaceholderStyle int
+
+const (
+	// PlaceholderQuestion is ?.
+	PlaceholderQuestion PlaceholderStyle = iota
+	// PlaceholderDollar is $1, $2...
+	PlaceholderDollar
+)
+
+// FeaturedDialect is implemented by dialects describing their features, the
+// zero DialectFeatures is used for others.
+type FeaturedDialect interface {
+	Features() DialectFeatures
+}
+
+func dialectFeatures(d DBDialect) DialectFeatures {
+	if fd, ok := d.(FeaturedDialect); ok {
+		return fd.Features()
+	}
+	return DialectFeatures{}
+}
+
+// DialectByName returns the dialect of database driver name: postgres, mysql
+// or sqlite3.
+func DialectByName(name string) (DBDialect, error) {
+	switch name {
+	case "postgres":
+		return Postgres{}, nil
+	case "mysql":
+		return MySQL{}, nil
+	case "sqlite3":
+		return SQLite3{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported dialect: %s", name)
+	}
+}
+
+// UpdatedTriggerer is implemented by dialects which can keep updated columns
+// correct by trigger, even for raw SQL writes. The trigger and its function
+// are named by name.
+type UpdatedTriggerer interface {
+	UpdatedTrigger(name, table, col string) []string
+}
+
 type Postgres struct{}
 
+var (
+	_ UpdatedTriggerer = Postgres{}
+	_ FeaturedDialect  = Postgres{}
+)
+
+func (Postgres) Features() DialectFeatures {
+	return DialectFeatures{
+		CreateIndexIfNotExists: true,
+		Alter:                  AlterStandard,
+		TransactionalDDL:       true,
+		Placeholder:            PlaceholderDollar,
+		DropCascade:            true,
+		MaxIdentifierLen:       63,
+		MaxPlaceholders:        65535,
+	}
+}
+
+// isTimeLiteral reports whether default value of time column is a literal
+// such as 2020-01-01 which must be quoted, expressions such as
+// CURRENT_TIMESTAMP are kept as is.
+func isTimeLiteral(val string) bool {
+	return val != "" && val[0] >= '0' && val[0] <= '9'
+}
+
 func (Postgres) defaultVal(def, val string, quote bool) string {
 	if val == "" {
 		val = def
@@ -70,6 +205,8 @@ func (p Postgres) Type(typ, precision, val string) (dbtyp, defval string, err er
 		return "TEXT", p.defaultVal("", val, true), nil
 	case "blob":
 		return "BYTEA", p.defaultVal(`E'\\000'`, val, false), nil
+	case "time":
+		return "TIMESTAMP WITH TIME ZONE", p.defaultVal("CURRENT_TIMESTAMP", val, isTimeLiteral(val)), nil
 	default:
 		return "", "", fmt.Errorf("postgres: unsupported type: %s", typ)
 	}
@@ -99,7 +236,44 @@ func (Postgres) DSN(config DBConfig) string {
 	)
 }
 
-type SQLite3 struct{}
+func (Postgres) UpdatedTrigger(name, table, col string) []string {
+	return []string{
+		fmt.Sprintf(`CREATE OR REPLACE FUNCTION "%s"() RETURNS TRIGGER AS $$
+BEGIN
+    NEW."%s" = CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+`, name, col),
+		fmt.Sprintf(`DROP TRIGGER IF EXISTS "%s" ON "%s";
+`, name, table),
+		fmt.Sprintf(`CREATE TRIGGER "%s" BEFORE UPDATE ON "%s" FOR EACH ROW EXECUTE PROCEDURE "%s"();
+`, name, table, name),
+	}
+}
+
+type SQLite3 struct {
+	// LockLease is how long a lock row stays valid, older rows are treated as
+	// left by crashed processes and taken over, then Unlock of the old holder
+	// returns ErrLockLost. It should exceed the longest work done under a
+	// lock, defaultSQLiteLockLease is used if zero.
+	LockLease time.Duration
+}
+
+var _ FeaturedDialect = SQLite3{}
+
+func (SQLite3) Features() DialectFeatures {
+	return DialectFeatures{
+		VirtualGenerated:       true,
+		CreateIndexIfNotExists: true,
+		Alter:                  AlterRebuild,
+		TransactionalDDL:       true,
+		ForwardForeignKeys:     true,
+		NoLimit:                "-1",
+		// SQLITE_MAX_VARIABLE_NUMBER since 3.32.0, it's 999 before
+		MaxPlaceholders: 32766,
+	}
+}
 
 func (SQLite3) DSN(config DBConfig) string {
 	if config.DBName == "" {
@@ -145,6 +319,8 @@ func (s SQLite3) Type(typ, precision, val string) (dbtyp, defval string, err err
 		return "TEXT", s.defaultVal("", val, true), nil
 	case "blob":
 		return "BLOB", s.defaultVal("x''", val, false), nil
+	case "time":
+		return "DATETIME", s.defaultVal("CURRENT_TIMESTAMP", val, isTimeLiteral(val)), nil
 	default:
 		return "", "", fmt.Errorf("sqlite3: unsupported type: %s", typ)
 	}
@@ -152,6 +328,23 @@ func (s SQLite3) Type(typ, precision, val string) (dbtyp, defval string, err err
 
 type MySQL struct{}
 
+var _ FeaturedDialect = MySQL{}
+
+func (MySQL) Features() DialectFeatures {
+	return DialectFeatures{
+		VirtualGenerated: true,
+		Alter:            AlterModify,
+		DropCascade:      true,
+		MaxIdentifierLen: 64,
+		TableScopedNames: true,
+		NoLimit:          "18446744073709551615",
+		MaxPlaceholders:  65535,
+		// default max_allowed_packet before 8.0
+		MaxPacketSize: 4 << 20,
+		Upsert:        UpsertOnDuplicateKey,
+	}
+}
+
 func (MySQL) defaultVal(def, val string, quote bool) string {
 	if val == "" {
 		val = def
@@ -220,6 +413,8 @@ func (m MySQL) Type(typ, precision, val string) (dbtyp, defval string, err error
 		return "MEDIUMTEXT", m.defaultVal("", val, true), nil
 	case "blob":
 		return "MEDIUMBLOB", m.defaultVal(``, val, false), nil
+	case "time":
+		return "DATETIME", m.defaultVal("CURRENT_TIMESTAMP", val, isTimeLiteral(val)), nil
 	default:
 		return "", "", fmt.Errorf("postgres: unsupported type: %s", typ)
 	}
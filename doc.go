@@ -3,7 +3,7 @@
 // Field tag format: `sqldb:"key[:value] key[:value]..."`. Available keys:
 //   table: table name
 //   col: column name, col:- to skip.
-//   type: char, text and Go builtin types: string/bool/int/uint/int8...
+//   type: char, text, time and Go builtin types: string/bool/int/uint/int8...
 //   precision: for string and char type, it's the 'length', such as precision:100,
 //              for float and double it's 'precision, exact', such as precision: 32,5.
 //   dbtype: the final database type, it will override type and precision key
@@ -11,6 +11,58 @@
 //   autoincr: auto increament
 //   notnull: not null
 //   default: default value, '-' to disable default
-//   unique: unique constraint name or empty
+//   unique: unique constraint names separated by comma or empty
+//   index: index names separated by comma or empty, columns with the same
+//          name form one index.
 //   fk: foreign key: TABLE.COLUMN
+//   softdelete: soft-delete marker, bool or nullable time column. SQLBuilder
+//               skips marked rows, Delete sets the marker.
+//   version: optimistic lock, integer column. SQLBuilder.Update increases it
+//            and checks the old value, see ErrOnStaleResult.
+//   created, updated: time column filled by SQLBuilder.Insert and Update, from
+//                     SQLBuilder.Clock or CURRENT_TIMESTAMP.
+//   generated: generated column expression, such as generated:'price * qty',
+//              stored by default. It's selected but never written.
+//   virtual: generated column is VIRTUAL instead of STORED.
+//   readonly: never written by SQLBuilder.Insert and Update.
+//   noinsert: never written by SQLBuilder.Insert.
+//   noupdate: never written by SQLBuilder.Update.
+//   group: comma separated column groups for projections, such as
+//          group:summary,list, see SQLUtil.TableColumnsGroup.
+//
+// Values quoted by single quotes may contain spaces, '' is an escaped quote.
+// Field of pointer type is nullable, time.Time is mapped to type time. Named
+// types are mapped by their kind, such as int for `type Status int`. Other
+// fields are NOT NULL if tagged by notnull or TableParserOptions.Notnull is
+// set, Column.Notnull reports it.
+//
+// Types that can't be tagged can be described by TableParserOptions.Mappings,
+// which supply the same settings keyed by Go type and field name. Tags win on
+// conflict.
+//
+// Constraints and indexes not named by tags are named by the NamingStrategy of
+// SQLUtil, names exceeding the identifier limit of dialect are shortened.
+//
+// SQLUtil.DiffSchema compares models with the live database and returns the
+// ALTER statements to migrate it, destructive and unsafe changes are flagged.
+// Migrator applies versioned migrations and records them in the
+// schema_migrations table, holding a cross-process lock shared with
+// SQLUtil.CreateTablesContext, see Locker and SQLUtil.WithLock. SQLite3 locks
+// are rows of the sqldb_locks table expiring after SQLite3.LockLease, so
+// CreateTables and Migrator create that table in the database on SQLite3.
+//
+// SQLBuilder.Select builds SELECT statements fluently, conditions use ?
+// placeholders and Build returns the statement rendered for the dialect with
+// its arguments. Expressions such as And(Eq("name", name), Gt("age", 18)) are
+// typed alternatives of raw conditions, their columns are checked against the
+// model table, see SelectBuilder.WhereExpr and SQLUtil.Cond.
+//
+// SQLUtil.Bind and BindNamed convert ? and :name parameters to placeholders of
+// dialect, slice arguments are expanded so that IN (:ids) works, empty ones
+// are rejected. Statements exceeding the parameter limit of dialect fail with
+// ErrTooManyPlaceholders.
+// InsertBatch splits rows into multi-row INSERT statements within the limits,
+// ExecInsertBatch executes them, optionally in one transaction.
+// SQLBuilder.Upsert and InsertOrIgnore render the native upsert of dialect,
+// ON CONFLICT or ON DUPLICATE KEY UPDATE.
 package sqldb
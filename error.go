@@ -1,6 +1,13 @@
 package sqldb
 
-import "database/sql"
+import (
+	"database/sql"
+	"errors"
+)
+
+// ErrStaleObject reports an update of a versioned row that has been changed
+// since it was read.
+var ErrStaleObject = errors.New("sqldb: stale object")
 
 func ErrOnNoRows(err, newErr error) error {
 	if err == sql.ErrNoRows {
@@ -24,3 +31,9 @@ func ErrOnNoAffectsResult(res sql.Result, err, newErr error) error {
 	n, err := ResultRowsAffected(res, err)
 	return ErrOnNoAffects(n, err, newErr)
 }
+
+// ErrOnStaleResult converts zero affected rows of a versioned update to
+// ErrStaleObject.
+func ErrOnStaleResult(res sql.Result, err error) error {
+	return ErrOnNoAffectsResult(res, err, ErrStaleObject)
+}
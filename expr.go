@@ -0,0 +1,233 @@
+package sqldb
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Expr is a condition expression, it's rendered with ? placeholders and
+// columns are checked against the model table.
+type Expr interface {
+	render(w *exprWriter) error
+}
+
+type exprWriter struct {
+	table Table
+	buf   strings.Builder
+	args  []interface{}
+}
+
+func (w *exprWriter) check(col string) error {
+	if _, has := w.table.Col(col); !has {
+		return fmt.Errorf("%s: column %s is not defined", w.table.Name, col)
+	}
+	return nil
+}
+
+func (w *exprWriter) column(col string) error {
+	if err := w.check(col); err != nil {
+		return err
+	}
+	w.buf.WriteString(col)
+	return nil
+}
+
+// arg writes a placeholder of v, slices are rejected since binding expands
+// them to a list, which is only valid in In.
+func (w *exprWriter) arg(v interface{}) error {
+	if _, ok := expandable(v); ok {
+		return fmt.Errorf("%s: slice value %T is only allowed in In", w.table.Name, v)
+	}
+	w.buf.WriteByte('?')
+	w.args = append(w.args, v)
+	return nil
+}
+
+// renderExpr renders e with ? placeholders and returns its arguments.
+func renderExpr(table Table, e Expr) (string, []interface{}, error) {
+	if e == nil {
+		return "", nil, fmt.Errorf("%s: nil expression", table.Name)
+	}
+	w := exprWriter{table: table}
+	if err := e.render(&w); err != nil {
+		return "", nil, err
+	}
+	return w.buf.String(), w.args, nil
+}
+
+// compareExpr is a binary comparison of column and value.
+type compareExpr struct {
+	col string
+	op  string
+	val interface{}
+}
+
+func (e compareExpr) render(w *exprWriter) error {
+	if err := w.column(e.col); err != nil {
+		return err
+	}
+	w.buf.WriteString(" " + e.op + " ")
+	return w.arg(e.val)
+}
+
+func Eq(col string, val interface{}) Expr { return compareExpr{col: col, op: "=", val: val} }
+func Ne(col string, val interface{}) Expr { return compareExpr{col: col, op: "<>", val: val} }
+func Lt(col string, val interface{}) Expr { return compareExpr{col: col, op: "<", val: val} }
+func Gt(col string, val interface{}) Expr { return compareExpr{col: col, op: ">", val: val} }
+
+// Like matches column with pattern, % and _ are wildcards.
+func Like(col string, pattern string) Expr { return compareExpr{col: col, op: "LIKE", val: pattern} }
+
+type inExpr struct {
+	col  string
+	vals []interface{}
+}
+
+// In matches column with any of values, it's always false if values is empty.
+// A single slice value is expanded.
+func In(col string, vals ...interface{}) Expr {
+	if len(vals) == 1 {
+		if refv, ok := expandable(vals[0]); ok {
+			vals = make([]interface{}, refv.Len())
+			for i := range vals {
+				vals[i] = refv.Index(i).Interface()
+			}
+		}
+	}
+	return inExpr{col: col, vals: vals}
+}
+
+func (e inExpr) render(w *exprWriter) error {
+	if err := w.check(e.col); err != nil {
+		return err
+	}
+	if len(e.vals) == 0 {
+		w.buf.WriteString("1 = 0")
+		return nil
+	}
+	w.buf.WriteString(e.col)
+	w.buf.WriteString(" IN (")
+	for i, v := range e.vals {
+		if i > 0 {
+			w.buf.WriteString(", ")
+		}
+		if err := w.arg(v); err != nil {
+			return err
+		}
+	}
+	w.buf.WriteByte(')')
+	return nil
+}
+
+type betweenExpr struct {
+	col       string
+	low, high interface{}
+}
+
+// Between matches column in the closed range [low, high].
+func Between(col string, low, high interface{}) Expr {
+	return betweenExpr{col: col, low: low, high: high}
+}
+
+func (e betweenExpr) render(w *exprWriter) error {
+	if err := w.column(e.col); err != nil {
+		return err
+	}
+	w.buf.WriteString(" BETWEEN ")
+	if err := w.arg(e.low); err != nil {
+		return err
+	}
+	w.buf.WriteString(" AND ")
+	return w.arg(e.high)
+}
+
+type isNullExpr string
+
+func IsNull(col string) Expr { return isNullExpr(col) }
+
+func (e isNullExpr) render(w *exprWriter) error {
+	if err := w.column(string(e)); err != nil {
+		return err
+	}
+	w.buf.WriteString(" IS NULL")
+	return nil
+}
+
+type notExpr struct {
+	expr Expr
+}
+
+func Not(e Expr) Expr { return notExpr{expr: e} }
+
+func (e notExpr) render(w *exprWriter) error {
+	if e.expr == nil {
+		return fmt.Errorf("%s: nil expression", w.table.Name)
+	}
+	w.buf.WriteString("NOT (")
+	if err := e.expr.render(w); err != nil {
+		return err
+	}
+	w.buf.WriteByte(')')
+	return nil
+}
+
+type logicExpr struct {
+	op    string
+	exprs []Expr
+}
+
+// And matches if all of exprs match, it's always true if exprs is empty.
+func And(exprs ...Expr) Expr { return logicExpr{op: "AND", exprs: exprs} }
+
+// Or matches if any of exprs matches, it's always false if exprs is empty.
+func Or(exprs ...Expr) Expr { return logicExpr{op: "OR", exprs: exprs} }
+
+func (e logicExpr) render(w *exprWriter) error {
+	switch len(e.exprs) {
+	case 0:
+		if e.op == "AND" {
+			w.buf.WriteString("1 = 1")
+		} else {
+			w.buf.WriteString("1 = 0")
+		}
+		return nil
+	case 1:
+		if e.exprs[0] == nil {
+			return fmt.Errorf("%s: nil expression", w.table.Name)
+		}
+		return e.exprs[0].render(w)
+	}
+	for i, sub := range e.exprs {
+		if sub == nil {
+			return fmt.Errorf("%s: nil expression", w.table.Name)
+		}
+		if i > 0 {
+			w.buf.WriteString(" " + e.op + " ")
+		}
+		_, nested := sub.(logicExpr)
+		if nested {
+			w.buf.WriteByte('(')
+		}
+		if err := sub.render(w); err != nil {
+			return err
+		}
+		if nested {
+			w.buf.WriteByte(')')
+		}
+	}
+	return nil
+}
+
+// Cond renders e of model with placeholders of dialect and returns its
+// arguments.
+func (s *SQLUtil) Cond(model interface{}, e Expr) (string, []interface{}, error) {
+	table, err := s.parser.StructTable(model)
+	if err != nil {
+		return "", nil, err
+	}
+	sql, args, err := renderExpr(table, e)
+	if err != nil {
+		return "", nil, err
+	}
+	return s.Bind(sql, args...)
+}
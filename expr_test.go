@@ -0,0 +1,86 @@
+package sqldb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpr(t *testing.T) {
+	type User struct {
+		Id    int64 `sqldb:"pk"`
+		Name  string
+		Email *string
+		Age   int
+	}
+	var (
+		p        = NewTableParser()
+		postgres = NewSQLUtil(p, Postgres{})
+		mysql    = NewSQLUtil(p, MySQL{})
+	)
+	type testCase struct {
+		SQL  *SQLUtil
+		Expr Expr
+
+		Expect string
+		Args   []interface{}
+	}
+	cases := []testCase{
+		{SQL: postgres, Expr: Eq("name", "bob"), Expect: "name = $1", Args: []interface{}{"bob"}},
+		{SQL: mysql, Expr: Eq("name", "bob"), Expect: "name = ?", Args: []interface{}{"bob"}},
+		{
+			SQL:    postgres,
+			Expr:   And(Ne("id", 1), Lt("age", 60), Gt("age", 18), Like("name", "b%")),
+			Expect: "id <> $1 AND age < $2 AND age > $3 AND name LIKE $4",
+			Args:   []interface{}{1, 60, 18, "b%"},
+		},
+		{
+			SQL:    postgres,
+			Expr:   Or(In("id", 1, 2, 3), And(Between("age", 18, 30), Not(IsNull("email")))),
+			Expect: "id IN ($1, $2, $3) OR (age BETWEEN $4 AND $5 AND NOT (email IS NULL))",
+			Args:   []interface{}{1, 2, 3, 18, 30},
+		},
+		{SQL: postgres, Expr: Not(In("id")), Expect: "NOT (1 = 0)"},
+		{SQL: postgres, Expr: And(), Expect: "1 = 1"},
+		{SQL: postgres, Expr: Or(), Expect: "1 = 0"},
+		{SQL: postgres, Expr: And(Or(Eq("id", 1))), Expect: "id = $1", Args: []interface{}{1}},
+	}
+	for i, c := range cases {
+		sql, args, err := c.SQL.Cond(User{}, c.Expr)
+		if err != nil {
+			t.Fatalf("%d: %s", i, err)
+		}
+		if sql != c.Expect {
+			t.Errorf("%d: expect %q, but got %q", i, c.Expect, sql)
+		}
+		if !reflect.DeepEqual(args, c.Args) {
+			t.Errorf("%d: expect args %v, but got %v", i, c.Args, args)
+		}
+	}
+
+	invalid := []Expr{
+		Eq("nmae", "bob"), In("idd"), Or(Eq("id", 1), Not(IsNull("mail"))), Not(nil), And(Eq("id", 1), nil),
+		Eq("name", []string{"a", "b"}), Ne("id", []int64{1}), Between("age", []int{1}, 2), In("id", []int{1}, []int{2}),
+	}
+	for i, e := range invalid {
+		if _, _, err := postgres.Cond(User{}, e); err == nil {
+			t.Errorf("%d: expect error for invalid expression", i)
+		}
+	}
+
+	sql, args, err := NewSQLBuilder(postgres).Select(User{}).Columns("id").WhereExpr(Eq("name", "bob")).Where("age > ?", 18).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := "SELECT id FROM user WHERE (name = $1) AND (age > $2)"; sql != expect {
+		t.Errorf("expect %q, but got %q", expect, sql)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"bob", 18}) {
+		t.Errorf("unexpected args %v", args)
+	}
+	if _, _, err = NewSQLBuilder(postgres).Select(User{}).WhereExpr(Gt("agee", 1)).Build(); err == nil {
+		t.Error("expect error for undefined column")
+	}
+	if _, _, err = NewSQLBuilder(postgres).Select(User{}).WhereExpr(Eq("name", []string{"a", "b"})).Build(); err == nil {
+		t.Error("expect error for comparing slice")
+	}
+}
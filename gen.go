@@ -0,0 +1,277 @@
+package sqldb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/format"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ModelField is a field of generated model struct.
+type ModelField struct {
+	Name string
+	Type reflect.Type
+	Tag  reflect.StructTag
+}
+
+// GeneratedModel is a struct generated from live table schema, parsing it by
+// a TableParser with Default option reproduces the table.
+type GeneratedModel struct {
+	Name   string
+	Table  string
+	Fields []ModelField
+}
+
+// StructType returns the struct type of model.
+func (m GeneratedModel) StructType() reflect.Type {
+	fields := make([]reflect.StructField, len(m.Fields))
+	for i, f := range m.Fields {
+		fields[i] = reflect.StructField{Name: f.Name, Type: f.Type, Tag: f.Tag}
+	}
+	return reflect.StructOf(fields)
+}
+
+// goTypes maps sqldb types to Go types, others are mapped to string and kept
+// by dbtype tag.
+var goTypes = map[string]reflect.Type{
+	"bool":    reflect.TypeOf(false),
+	"int":     reflect.TypeOf(int(0)),
+	"int8":    reflect.TypeOf(int8(0)),
+	"int16":   reflect.TypeOf(int16(0)),
+	"int32":   reflect.TypeOf(int32(0)),
+	"int64":   reflect.TypeOf(int64(0)),
+	"uint":    reflect.TypeOf(uint(0)),
+	"uint8":   reflect.TypeOf(uint8(0)),
+	"uint16":  reflect.TypeOf(uint16(0)),
+	"uint32":  reflect.TypeOf(uint32(0)),
+	"uint64":  reflect.TypeOf(uint64(0)),
+	"float32": reflect.TypeOf(float32(0)),
+	"float64": reflect.TypeOf(float64(0)),
+	"float":   reflect.TypeOf(float64(0)),
+	"string":  reflect.TypeOf(""),
+	"char":    reflect.TypeOf(""),
+	"text":    reflect.TypeOf(""),
+	"time":    reflect.TypeOf(time.Time{}),
+	"blob":    reflect.TypeOf([]byte(nil)),
+}
+
+// ModelOf returns the model reproducing live table schema.
+func (s *SQLUtil) ModelOf(schema TableSchema) GeneratedModel {
+	// unique indexes are kept as unique constraints, every column of an index
+	// is tagged by its name
+	var (
+		indexes = make(map[string][]string)
+		uniques = make(map[string][]string)
+	)
+	for _, index := range schema.Indexes {
+		for _, col := range index.Cols {
+			if index.Unique {
+				uniques[col] = append(uniques[col], index.Name)
+			} else {
+				indexes[col] = append(indexes[col], index.Name)
+			}
+		}
+	}
+	model := GeneratedModel{Name: exportedName(schema.Name), Table: schema.Name}
+	names := make(map[string]bool)
+	for i, col := range schema.Cols {
+		conds := []tagCond{{Name: "col", Val: col.Name}}
+		if i == 0 {
+			conds = append([]tagCond{{Name: "table", Val: schema.Name}}, conds...)
+		}
+
+		typ, has := goTypes[col.Type]
+		if !has {
+			typ = goTypes["string"]
+		} else if typ.Kind() == reflect.String && col.Type != "string" {
+			conds = append(conds, tagCond{Name: "type", Val: col.Type})
+		}
+		if has && col.Precision != "" {
+			conds = append(conds, tagCond{Name: "precision", Val: col.Precision})
+		}
+		dbTyp, defaultVal, err := s.dialect.Type(col.Type, col.Precision, "")
+		if !has || err != nil || !s.sameType(dbTyp, col.DBType) {
+			conds = append(conds, tagCond{Name: "dbtype", Val: col.DBType})
+		}
+		if col.Notnull {
+			conds = append(conds, tagCond{Name: "notnull"})
+		} else {
+			typ = reflect.PointerTo(typ)
+		}
+		if col.Primary {
+			conds = append(conds, tagCond{Name: "pk"})
+		}
+		if col.AutoIncr {
+			conds = append(conds, tagCond{Name: "autoincr"})
+		}
+		switch {
+		case col.Generated != "":
+			conds = append(conds, tagCond{Name: "generated", Val: col.Generated})
+			if col.Virtual {
+				conds = append(conds, tagCond{Name: "virtual"})
+			}
+		case col.Default && err == nil && normalizeDefault(defaultVal) == normalizeDefault(col.DefaultVal):
+			conds = append(conds, tagCond{Name: "default"})
+		case col.Default:
+			conds = append(conds, tagCond{Name: "default", Val: modelDefault(col)})
+		default:
+			conds = append(conds, tagCond{Name: "default", Val: "-"})
+		}
+		uniqueNames := uniques[col.Name]
+		if col.Unique {
+			uniqueNames = append(strings.Split(col.UniqueName, ","), uniqueNames...)
+		}
+		if len(uniqueNames) > 0 {
+			conds = append(conds, tagCond{Name: "unique", Val: joinNames(uniqueNames)})
+		}
+		if names := indexes[col.Name]; len(names) > 0 {
+			conds = append(conds, tagCond{Name: "index", Val: joinNames(names)})
+		}
+		if col.ForeignTable != "" {
+			conds = append(conds, tagCond{Name: "fk", Val: col.ForeignTable + "." + col.ForeignCol})
+		}
+
+		name := exportedName(col.Name)
+		for names[name] {
+			name += "_"
+		}
+		names[name] = true
+		model.Fields = append(model.Fields, ModelField{
+			Name: name,
+			Type: typ,
+			Tag:  reflect.StructTag(s.parser.opts.FieldTag + ":" + strconv.Quote(joinTagConds(conds))),
+		})
+	}
+	return model
+}
+
+// joinNames joins constraint or index names of tag by comma, duplicated ones
+// are dropped.
+func joinNames(names []string) string {
+	var joined []string
+	for _, name := range names {
+		var has bool
+		for _, n := range joined {
+			has = has || n == name
+		}
+		if !has {
+			joined = append(joined, name)
+		}
+	}
+	return strings.Join(joined, ",")
+}
+
+// modelDefault converts raw default expression of column to default tag
+// value, quotes of strings are stripped since dialects add them.
+func modelDefault(col Column) string {
+	v := strings.TrimSpace(col.DefaultVal)
+	for len(v) >= 2 && v[0] == '(' && v[len(v)-1] == ')' {
+		v = strings.TrimSpace(v[1 : len(v)-1])
+	}
+	if i := strings.LastIndex(v, "::"); i > 0 && !strings.Contains(v[i:], "'") {
+		v = v[:i]
+	}
+	if len(v) >= 2 && v[0] == '\'' && v[len(v)-1] == '\'' {
+		v = strings.Replace(v[1:len(v)-1], "''", "'", -1)
+	}
+	return v
+}
+
+// joinTagConds is the reverse of splitTagConds.
+func joinTagConds(conds []tagCond) string {
+	var buf strings.Builder
+	for i, cond := range conds {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(cond.Name)
+		if cond.Val == "" {
+			continue
+		}
+		buf.WriteByte(':')
+		if strings.ContainsAny(cond.Val, " '") {
+			buf.WriteString("'" + strings.Replace(cond.Val, "'", "''", -1) + "'")
+		} else {
+			buf.WriteString(cond.Val)
+		}
+	}
+	return buf.String()
+}
+
+// exportedName converts snake case name to exported Go identifier.
+func exportedName(name string) string {
+	var buf strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		buf.WriteRune(r)
+	}
+	s := buf.String()
+	if s == "" || !unicode.IsUpper([]rune(s)[0]) {
+		s = "X" + s
+	}
+	return s
+}
+
+// WriteModels writes formatted Go source of models in package pkg.
+func WriteModels(w io.Writer, pkg string, models ...GeneratedModel) error {
+	var (
+		body    bytes.Buffer
+		imports = make(map[string]bool)
+	)
+	for _, m := range models {
+		fmt.Fprintf(&body, "\ntype %s struct {\n", m.Name)
+		for _, f := range m.Fields {
+			typ := strings.Replace(f.Type.String(), "[]uint8", "[]byte", -1)
+			if strings.Contains(typ, "time.") {
+				imports["time"] = true
+			}
+			tag := "`" + string(f.Tag) + "`"
+			if strings.Contains(string(f.Tag), "`") {
+				tag = strconv.Quote(string(f.Tag))
+			}
+			fmt.Fprintf(&body, "\t%s %s %s\n", f.Name, typ, tag)
+		}
+		body.WriteString("}\n")
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by sqldb-gen. DO NOT EDIT.\n\npackage %s\n", pkg)
+	if imports["time"] {
+		src.WriteString("\nimport \"time\"\n")
+	}
+	src.Write(body.Bytes())
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(formatted)
+	return err
+}
+
+// GenerateModels inspects named tables, or all tables if names is empty, and
+// writes the models reproducing them in package pkg.
+func (s *SQLUtil) GenerateModels(ctx context.Context, q Queryer, w io.Writer, pkg string, names ...string) error {
+	schemas, err := s.InspectSchema(ctx, q, names...)
+	if err != nil {
+		return err
+	}
+	models := make([]GeneratedModel, len(schemas))
+	for i, schema := range schemas {
+		models[i] = s.ModelOf(schema)
+	}
+	return WriteModels(w, pkg, models...)
+}
@@ -0,0 +1,92 @@
+package sqldb
+
+import (
+	"bytes"
+	"context"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateModels(t *testing.T) {
+	type Author struct {
+		Id      int64   `sqldb:"pk"`
+		Email   string  `sqldb:"unique"`
+		Name    string  `sqldb:"precision:32 default:'it''s me' index:ix_author_name,ix_author_name_bio"`
+		Bio     *string `sqldb:"index:ix_author_name_bio"`
+		Created time.Time
+	}
+	type Book struct {
+		Id      int64   `sqldb:"pk"`
+		Author  int64   `sqldb:"fk:author.id"`
+		Title   string  `sqldb:"type:text unique:uq_book_title index"`
+		Isbn    string  `sqldb:"unique:uq_book_title,uq_book_isbn"`
+		Price   float64 `sqldb:"default:1.5"`
+		Total   float64 `sqldb:"generated:'price * 2'"`
+		Content []byte
+	}
+	ctx := context.Background()
+	db := openSQLite3(t)
+	opts := TableParserOptions{Default: true, Notnull: true}
+	su := NewSQLUtil(NewTableParser(opts), SQLite3{})
+	if err := su.CreateTables(db, Author{}, Book{}); err != nil {
+		t.Fatal(err)
+	}
+
+	schemas, err := su.InspectSchema(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a fresh parser without the naming mapper, tags must be sufficient
+	genParser := NewTableParser(opts, TableParserOptions{NameMapper: func(s string) string { return s + "_x" }})
+	for i, model := range []interface{}{Author{}, Book{}} {
+		table, err := su.TableParser().StructTable(model)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect, err := su.CreateTableSQL(table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		genTable, err := genParser.TypeTable(su.ModelOf(schemas[i]).StructType())
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := su.CreateTableSQL(genTable)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != expect {
+			t.Errorf("generated model should reproduce table:\n%s\n%s", expect, got)
+		}
+		if strings.Join(su.CreateIndexSQL(genTable), "") != strings.Join(su.CreateIndexSQL(table), "") {
+			t.Errorf("generated model should reproduce indexes: %q", su.CreateIndexSQL(genTable))
+		}
+	}
+
+	var buf bytes.Buffer
+	if err = su.GenerateModels(ctx, db, &buf, "models"); err != nil {
+		t.Fatal(err)
+	}
+	src := buf.String()
+	if _, err = parser.ParseFile(token.NewFileSet(), "models.go", src, 0); err != nil {
+		t.Fatal(err, src)
+	}
+	for _, s := range []string{
+		"package models",
+		`import "time"`,
+		"type Author struct {",
+		"Bio     *string",
+		`Name    string    ` + "`" + `sqldb:"col:name notnull default:'it''s me' index:ix_author_name,ix_author_name_bio"` + "`",
+		`Bio     *string   ` + "`" + `sqldb:"col:bio default:- index:ix_author_name_bio"` + "`",
+		"Content []byte  `sqldb:\"col:content notnull default\"`",
+		"unique:uq_book_title,uq_book_isbn\"`",
+		`Id      int64     ` + "`" + `sqldb:"table:author col:id notnull pk default"`,
+	} {
+		if !strings.Contains(src, s) {
+			t.Errorf("expect %q in generated source:\n%s", s, src)
+		}
+	}
+}
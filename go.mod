@@ -1 +1,5 @@
 module github.com/cosiner/go-sqldb
+
+go 1.21
+
+require github.com/mattn/go-sqlite3 v1.14.22
@@ -0,0 +1,162 @@
+package sqldb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoTable is returned by SchemaInspector.InspectTable if the table does not
+// exist.
+var ErrNoTable = errors.New("sqldb: table does not exist")
+
+const (
+	ConstraintPrimaryKey = "PRIMARY KEY"
+	ConstraintUnique     = "UNIQUE"
+	ConstraintForeignKey = "FOREIGN KEY"
+)
+
+type Index struct {
+	Name   string
+	Cols   []string
+	Unique bool
+}
+
+type Constraint struct {
+	Name         string
+	Type         string
+	Cols         []string
+	ForeignTable string
+	ForeignCols  []string
+}
+
+// TableSchema is the live schema of a table. Columns have DBType as reported
+// by database, Type and Precision mapped back to sqldb types, and DefaultVal
+// as the raw SQL expression. Indexes don't include those backing constraints.
+type TableSchema struct {
+	Table
+	Indexes     []Index
+	Constraints []Constraint
+}
+
+func (t TableSchema) Constraint(typ string) []Constraint {
+	var cs []Constraint
+	for _, c := range t.Constraints {
+		if c.Type == typ {
+			cs = append(cs, c)
+		}
+	}
+	return cs
+}
+
+// SchemaInspector is implemented by dialects able to read schema back from
+// database.
+type SchemaInspector interface {
+	TableNames(ctx context.Context, q Queryer) ([]string, error)
+	InspectTable(ctx context.Context, q Queryer, name string) (TableSchema, error)
+}
+
+func (s *SQLUtil) schemaInspector() (SchemaInspector, error) {
+	inspector, ok := s.dialect.(SchemaInspector)
+	if !ok {
+		return nil, fmt.Errorf("dialect %T doesn't support schema inspection", s.dialect)
+	}
+	return inspector, nil
+}
+
+// InspectSchema reads schema of named tables, or all tables if names is empty.
+func (s *SQLUtil) InspectSchema(ctx context.Context, q Queryer, names ...string) ([]TableSchema, error) {
+	inspector, err := s.schemaInspector()
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		names, err = inspector.TableNames(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+	}
+	schemas := make([]TableSchema, 0, len(names))
+	for _, name := range names {
+		schema, err := inspector.InspectTable(ctx, q, name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		schemas = append(schemas, schema)
+	}
+	return schemas, nil
+}
+
+func queryStrings(ctx context.Context, q Queryer, query string, args ...interface{}) ([]string, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ss []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		ss = append(ss, s)
+	}
+	return ss, rows.Err()
+}
+
+// applyConstraints fills primary, unique and foreign key settings of columns
+// from table constraints.
+func (t *TableSchema) applyConstraints() {
+	for i := range t.Cols {
+		col := &t.Cols[i]
+		for _, c := range t.Constraints {
+			idx := -1
+			for j, name := range c.Cols {
+				if name == col.Name {
+					idx = j
+					break
+				}
+			}
+			if idx < 0 {
+				continue
+			}
+			switch c.Type {
+			case ConstraintPrimaryKey:
+				col.Primary = true
+			case ConstraintUnique:
+				if col.Unique {
+					col.UniqueName += ","
+				}
+				col.Unique = true
+				col.UniqueName += c.Name
+			case ConstraintForeignKey:
+				if len(c.Cols) == 1 && len(c.ForeignCols) == 1 {
+					col.ForeignTable = c.ForeignTable
+					col.ForeignCol = c.ForeignCols[0]
+				}
+			}
+		}
+	}
+}
+
+// splitTypePrecision splits "type(precision)" into lower-cased type and
+// precision.
+func splitTypePrecision(dbType string) (string, string) {
+	typ := strings.ToLower(strings.TrimSpace(dbType))
+	var precision string
+	if i := strings.Index(typ, "("); i >= 0 {
+		if j := strings.LastIndex(typ, ")"); j > i {
+			precision = strings.Replace(typ[i+1:j], " ", "", -1)
+			typ = strings.TrimSpace(typ[:i] + typ[j+1:])
+		}
+	}
+	return typ, precision
+}
+
+func splitNames(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
@@ -0,0 +1,181 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+var _ SchemaInspector = MySQL{}
+
+func (MySQL) TableNames(ctx context.Context, q Queryer) ([]string, error) {
+	return queryStrings(ctx, q, `SELECT table_name FROM information_schema.tables
+WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE'
+ORDER BY table_name`)
+}
+
+func (m MySQL) InspectTable(ctx context.Context, q Queryer, name string) (TableSchema, error) {
+	schema := TableSchema{Table: Table{Name: name}}
+	rows, err := q.QueryContext(ctx, `SELECT column_name, column_type, is_nullable, column_default, extra, generation_expression
+FROM information_schema.columns
+WHERE table_schema = DATABASE() AND table_name = ?
+ORDER BY ordinal_position`, name)
+	if err != nil {
+		return schema, err
+	}
+	for rows.Next() {
+		var (
+			col                 Column
+			nullable, extra     string
+			dflt, generationExp sql.NullString
+		)
+		if err = rows.Scan(&col.Name, &col.DBType, &nullable, &dflt, &extra, &generationExp); err != nil {
+			rows.Close()
+			return schema, err
+		}
+		col.Type, col.Precision = m.inspectedType(col.DBType)
+		col.Notnull = nullable == "NO"
+		col.Default = dflt.Valid
+		col.DefaultVal = dflt.String
+		extra = strings.ToUpper(extra)
+		col.AutoIncr = strings.Contains(extra, "AUTO_INCREMENT")
+		if strings.Contains(extra, "GENERATED") && generationExp.String != "" {
+			col.Generated = generationExp.String
+			col.Virtual = strings.Contains(extra, "VIRTUAL")
+		}
+		schema.Cols = append(schema.Cols, col)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return schema, err
+	}
+	if len(schema.Cols) == 0 {
+		return schema, ErrNoTable
+	}
+
+	rows, err = q.QueryContext(ctx, `SELECT tc.constraint_name, tc.constraint_type, kcu.column_name,
+	COALESCE(kcu.referenced_table_name, ''), COALESCE(kcu.referenced_column_name, '')
+FROM information_schema.table_constraints tc
+	JOIN information_schema.key_column_usage kcu ON kcu.constraint_schema = tc.constraint_schema
+		AND kcu.table_name = tc.table_name AND kcu.constraint_name = tc.constraint_name
+WHERE tc.table_schema = DATABASE() AND tc.table_name = ?
+	AND tc.constraint_type IN ('PRIMARY KEY', 'UNIQUE', 'FOREIGN KEY')
+ORDER BY tc.constraint_type DESC, tc.constraint_name, kcu.ordinal_position`, name)
+	if err != nil {
+		return schema, err
+	}
+	for rows.Next() {
+		var (
+			c               Constraint
+			col, foreignCol string
+		)
+		if err = rows.Scan(&c.Name, &c.Type, &col, &c.ForeignTable, &foreignCol); err != nil {
+			rows.Close()
+			return schema, err
+		}
+		if n := len(schema.Constraints); n > 0 && schema.Constraints[n-1].Name == c.Name && schema.Constraints[n-1].Type == c.Type {
+			last := &schema.Constraints[n-1]
+			last.Cols = append(last.Cols, col)
+			if foreignCol != "" {
+				last.ForeignCols = append(last.ForeignCols, foreignCol)
+			}
+			continue
+		}
+		c.Cols = []string{col}
+		if foreignCol != "" {
+			c.ForeignCols = []string{foreignCol}
+		}
+		schema.Constraints = append(schema.Constraints, c)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return schema, err
+	}
+
+	rows, err = q.QueryContext(ctx, `SELECT index_name, non_unique, column_name
+FROM information_schema.statistics
+WHERE table_schema = DATABASE() AND table_name = ?
+ORDER BY index_name, seq_in_index`, name)
+	if err != nil {
+		return schema, err
+	}
+	for rows.Next() {
+		var (
+			indexName, col string
+			nonUnique      int
+		)
+		if err = rows.Scan(&indexName, &nonUnique, &col); err != nil {
+			rows.Close()
+			return schema, err
+		}
+		var backing bool
+		for _, c := range schema.Constraints {
+			if c.Name == indexName {
+				backing = true
+				break
+			}
+		}
+		if backing {
+			continue
+		}
+		if n := len(schema.Indexes); n > 0 && schema.Indexes[n-1].Name == indexName {
+			schema.Indexes[n-1].Cols = append(schema.Indexes[n-1].Cols, col)
+			continue
+		}
+		schema.Indexes = append(schema.Indexes, Index{Name: indexName, Cols: []string{col}, Unique: nonUnique == 0})
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return schema, err
+	}
+	schema.applyConstraints()
+	return schema, nil
+}
+
+func (MySQL) inspectedType(dbType string) (typ, precision string) {
+	typ, precision = splitTypePrecision(dbType)
+	unsigned := strings.HasSuffix(typ, " unsigned")
+	typ = strings.TrimSuffix(typ, " unsigned")
+	switch typ {
+	case "tinyint":
+		if precision == "1" {
+			return "bool", ""
+		}
+		if unsigned {
+			return "uint8", ""
+		}
+		return "int8", ""
+	case "boolean", "bool":
+		return "bool", ""
+	case "smallint":
+		if unsigned {
+			return "uint16", ""
+		}
+		return "int16", ""
+	case "int", "integer", "mediumint":
+		if unsigned {
+			return "uint32", ""
+		}
+		return "int32", ""
+	case "bigint":
+		if unsigned {
+			return "uint64", ""
+		}
+		return "int64", ""
+	case "float":
+		return "float32", precision
+	case "double", "decimal":
+		return "float64", precision
+	case "varchar":
+		return "string", precision
+	case "char":
+		return "char", precision
+	case "text", "mediumtext", "longtext", "tinytext":
+		return "text", ""
+	case "blob", "mediumblob", "longblob", "tinyblob", "varbinary", "binary":
+		return "blob", ""
+	case "datetime", "timestamp", "date":
+		return "time", ""
+	}
+	return typ, precision
+}
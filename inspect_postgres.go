@@ -0,0 +1,164 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+var _ SchemaInspector = Postgres{}
+
+func (Postgres) TableNames(ctx context.Context, q Queryer) ([]string, error) {
+	return queryStrings(ctx, q, `SELECT table_name FROM information_schema.tables
+WHERE table_schema = current_schema() AND table_type = 'BASE TABLE'
+ORDER BY table_name`)
+}
+
+func (p Postgres) InspectTable(ctx context.Context, q Queryer, name string) (TableSchema, error) {
+	schema := TableSchema{Table: Table{Name: name}}
+	rows, err := q.QueryContext(ctx, `SELECT a.attname, format_type(a.atttypid, a.atttypmod), a.attnotnull,
+	COALESCE(pg_get_expr(d.adbin, d.adrelid), ''), d.adbin IS NOT NULL, a.attidentity != '', a.attgenerated != ''
+FROM pg_attribute a
+	JOIN pg_class c ON c.oid = a.attrelid
+	JOIN pg_namespace n ON n.oid = c.relnamespace
+	LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+WHERE n.nspname = current_schema() AND c.relname = $1 AND c.relkind = 'r' AND a.attnum > 0 AND NOT a.attisdropped
+ORDER BY a.attnum`, name)
+	if err != nil {
+		return schema, err
+	}
+	for rows.Next() {
+		var (
+			col                          Column
+			expr                         string
+			hasDefault, ident, generated bool
+		)
+		if err = rows.Scan(&col.Name, &col.DBType, &col.Notnull, &expr, &hasDefault, &ident, &generated); err != nil {
+			rows.Close()
+			return schema, err
+		}
+		col.Type, col.Precision = p.inspectedType(col.DBType)
+		switch {
+		case generated:
+			col.Generated = expr
+		case ident:
+			col.AutoIncr = true
+		case hasDefault:
+			col.Default = true
+			col.DefaultVal = expr
+			col.AutoIncr = strings.HasPrefix(expr, "nextval(")
+		}
+		schema.Cols = append(schema.Cols, col)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return schema, err
+	}
+	if len(schema.Cols) == 0 {
+		return schema, ErrNoTable
+	}
+
+	rows, err = q.QueryContext(ctx, `SELECT con.conname, con.contype,
+	array_to_string(ARRAY(SELECT a.attname FROM unnest(con.conkey) WITH ORDINALITY k(n, o)
+		JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.n ORDER BY k.o), ','),
+	COALESCE(fc.relname, ''),
+	array_to_string(ARRAY(SELECT a.attname FROM unnest(con.confkey) WITH ORDINALITY k(n, o)
+		JOIN pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.n ORDER BY k.o), ',')
+FROM pg_constraint con
+	JOIN pg_class c ON c.oid = con.conrelid
+	JOIN pg_namespace n ON n.oid = c.relnamespace
+	LEFT JOIN pg_class fc ON fc.oid = con.confrelid
+WHERE n.nspname = current_schema() AND c.relname = $1 AND con.contype IN ('p', 'u', 'f')
+ORDER BY con.contype DESC, con.conname`, name)
+	if err != nil {
+		return schema, err
+	}
+	for rows.Next() {
+		var (
+			c                      Constraint
+			typ, cols, foreignCols string
+		)
+		if err = rows.Scan(&c.Name, &typ, &cols, &c.ForeignTable, &foreignCols); err != nil {
+			rows.Close()
+			return schema, err
+		}
+		switch typ {
+		case "p":
+			c.Type = ConstraintPrimaryKey
+		case "u":
+			c.Type = ConstraintUnique
+		case "f":
+			c.Type = ConstraintForeignKey
+		}
+		c.Cols = splitNames(cols)
+		c.ForeignCols = splitNames(foreignCols)
+		schema.Constraints = append(schema.Constraints, c)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return schema, err
+	}
+
+	rows, err = q.QueryContext(ctx, `SELECT i.relname, ix.indisunique,
+	array_to_string(ARRAY(SELECT a.attname FROM unnest(ix.indkey::int2[]) WITH ORDINALITY k(n, o)
+		JOIN pg_attribute a ON a.attrelid = ix.indrelid AND a.attnum = k.n ORDER BY k.o), ',')
+FROM pg_index ix
+	JOIN pg_class i ON i.oid = ix.indexrelid
+	JOIN pg_class c ON c.oid = ix.indrelid
+	JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = current_schema() AND c.relname = $1
+	AND NOT EXISTS (SELECT 1 FROM pg_constraint con WHERE con.conindid = ix.indexrelid AND con.conrelid = ix.indrelid)
+ORDER BY i.relname`, name)
+	if err != nil {
+		return schema, err
+	}
+	for rows.Next() {
+		var (
+			idx  Index
+			cols sql.NullString
+		)
+		if err = rows.Scan(&idx.Name, &idx.Unique, &cols); err != nil {
+			rows.Close()
+			return schema, err
+		}
+		idx.Cols = splitNames(cols.String)
+		schema.Indexes = append(schema.Indexes, idx)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return schema, err
+	}
+	schema.applyConstraints()
+	return schema, nil
+}
+
+func (Postgres) inspectedType(dbType string) (typ, precision string) {
+	typ, precision = splitTypePrecision(dbType)
+	switch typ {
+	case "boolean", "bool":
+		return "bool", ""
+	case "smallint", "int2":
+		return "int16", ""
+	case "integer", "int", "int4":
+		return "int32", ""
+	case "bigint", "int8":
+		return "int64", ""
+	case "real", "float4":
+		return "float32", ""
+	case "double precision", "float8":
+		return "float64", ""
+	case "numeric", "decimal":
+		return "float64", precision
+	case "character varying", "varchar":
+		return "string", precision
+	case "character", "char", "bpchar":
+		return "char", precision
+	case "text":
+		return "text", ""
+	case "bytea":
+		return "blob", ""
+	case "date", "timestamp with time zone", "timestamp without time zone", "timestamptz", "timestamp":
+		return "time", ""
+	}
+	return typ, precision
+}
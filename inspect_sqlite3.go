@@ -0,0 +1,320 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var _ SchemaInspector = SQLite3{}
+
+// TableNames skips internal tables, including the lock table of SQLite3.Lock.
+func (SQLite3) TableNames(ctx context.Context, q Queryer) ([]string, error) {
+	return queryStrings(ctx, q, "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name <> ? ORDER BY name", sqliteLockTable)
+}
+
+func (s SQLite3) InspectTable(ctx context.Context, q Queryer, name string) (TableSchema, error) {
+	schema := TableSchema{Table: Table{Name: name}}
+	createSQLs, err := queryStrings(ctx, q, "SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?", name)
+	if err != nil {
+		return schema, err
+	}
+	if len(createSQLs) == 0 {
+		return schema, ErrNoTable
+	}
+	defs := sqliteTableDefs(createSQLs[0])
+
+	var pks []struct {
+		Seq  int
+		Name string
+	}
+	rows, err := q.QueryContext(ctx, `SELECT name, type, "notnull", dflt_value, pk, hidden FROM pragma_table_xinfo(?) ORDER BY cid`, name)
+	if err != nil {
+		return schema, err
+	}
+	for rows.Next() {
+		var (
+			col        Column
+			dflt       sql.NullString
+			pk, hidden int
+		)
+		if err = rows.Scan(&col.Name, &col.DBType, &col.Notnull, &dflt, &pk, &hidden); err != nil {
+			rows.Close()
+			return schema, err
+		}
+		col.Type, col.Precision = s.inspectedType(col.DBType)
+		col.Default = dflt.Valid
+		col.DefaultVal = dflt.String
+		def := defs.column(col.Name)
+		if hidden == 2 || hidden == 3 {
+			col.Generated = sqliteGeneratedExpr(def)
+			col.Virtual = hidden == 2
+		}
+		if pk > 0 {
+			pks = append(pks, struct {
+				Seq  int
+				Name string
+			}{pk, col.Name})
+			col.AutoIncr = strings.Contains(strings.ToUpper(def), "AUTOINCREMENT")
+		}
+		schema.Cols = append(schema.Cols, col)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return schema, err
+	}
+	if len(pks) > 0 {
+		sort.Slice(pks, func(i, j int) bool { return pks[i].Seq < pks[j].Seq })
+		c := Constraint{Type: ConstraintPrimaryKey}
+		for _, pk := range pks {
+			c.Cols = append(c.Cols, pk.Name)
+		}
+		c.Name = defs.constraintName(c.Type, c.Cols)
+		schema.Constraints = append(schema.Constraints, c)
+	}
+
+	type indexInfo struct {
+		Name   string
+		Unique bool
+		Origin string
+	}
+	var indexes []indexInfo
+	rows, err = q.QueryContext(ctx, `SELECT name, "unique", origin FROM pragma_index_list(?) ORDER BY name`, name)
+	if err != nil {
+		return schema, err
+	}
+	for rows.Next() {
+		var idx indexInfo
+		if err = rows.Scan(&idx.Name, &idx.Unique, &idx.Origin); err != nil {
+			rows.Close()
+			return schema, err
+		}
+		indexes = append(indexes, idx)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return schema, err
+	}
+	for _, idx := range indexes {
+		if idx.Origin == "pk" {
+			continue
+		}
+		cols, err := queryStrings(ctx, q, "SELECT name FROM pragma_index_info(?) ORDER BY seqno", idx.Name)
+		if err != nil {
+			return schema, err
+		}
+		if idx.Origin == "u" {
+			schema.Constraints = append(schema.Constraints, Constraint{
+				Name: defs.constraintName(ConstraintUnique, cols),
+				Type: ConstraintUnique,
+				Cols: cols,
+			})
+		} else {
+			schema.Indexes = append(schema.Indexes, Index{Name: idx.Name, Cols: cols, Unique: idx.Unique})
+		}
+	}
+
+	rows, err = q.QueryContext(ctx, `SELECT id, "table", "from", "to" FROM pragma_foreign_key_list(?) ORDER BY id, seq`, name)
+	if err != nil {
+		return schema, err
+	}
+	var (
+		fks  []Constraint
+		fkID = -1
+	)
+	for rows.Next() {
+		var (
+			id              int
+			table, from, to string
+		)
+		if err = rows.Scan(&id, &table, &from, &to); err != nil {
+			rows.Close()
+			return schema, err
+		}
+		if id != fkID {
+			fkID = id
+			fks = append(fks, Constraint{Type: ConstraintForeignKey, ForeignTable: table})
+		}
+		fk := &fks[len(fks)-1]
+		fk.Cols = append(fk.Cols, from)
+		fk.ForeignCols = append(fk.ForeignCols, to)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return schema, err
+	}
+	for i := range fks {
+		fks[i].Name = defs.constraintName(ConstraintForeignKey, fks[i].Cols)
+	}
+	schema.Constraints = append(schema.Constraints, fks...)
+	schema.applyConstraints()
+	return schema, nil
+}
+
+func (SQLite3) inspectedType(dbType string) (typ, precision string) {
+	typ, precision = splitTypePrecision(dbType)
+	upper := strings.ToUpper(typ)
+	switch {
+	case strings.Contains(upper, "BOOL"):
+		return "bool", ""
+	case strings.Contains(upper, "DATE"), strings.Contains(upper, "TIME"):
+		return "time", ""
+	case strings.Contains(upper, "INT"):
+		return "int64", ""
+	case strings.Contains(upper, "CHAR"), strings.Contains(upper, "CLOB"), strings.Contains(upper, "TEXT"):
+		return "string", precision
+	case upper == "", strings.Contains(upper, "BLOB"):
+		return "blob", ""
+	default:
+		return "float64", ""
+	}
+}
+
+// sqliteDefs are column and table constraint definitions of CREATE TABLE,
+// SQLite doesn't report constraint names and generated expressions by
+// pragmas.
+type sqliteDefs []string
+
+func sqliteTableDefs(createSQL string) sqliteDefs {
+	start := strings.Index(createSQL, "(")
+	end := strings.LastIndex(createSQL, ")")
+	if start < 0 || end < start {
+		return nil
+	}
+	var defs sqliteDefs
+	for _, def := range splitTopLevel(createSQL[start+1:end], ',') {
+		defs = append(defs, strings.TrimSpace(def))
+	}
+	return defs
+}
+
+// splitTopLevel splits s by sep outside of parentheses and quotes.
+func splitTopLevel(s string, sep rune) []string {
+	var (
+		parts []string
+		depth int
+		quote rune
+		last  int
+	)
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == sep && depth == 0:
+			parts = append(parts, s[last:i])
+			last = i + 1
+		}
+	}
+	return append(parts, s[last:])
+}
+
+// sqlIdents splits s into identifiers and unquotes them.
+func sqlIdents(s string) []string {
+	var names []string
+	for _, name := range splitTopLevel(s, ',') {
+		names = append(names, unquoteIdent(strings.TrimSpace(name)))
+	}
+	return names
+}
+
+func unquoteIdent(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '`' || s[0] == '[') {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// firstToken returns the first identifier of s, quoted or not, and the rest.
+func firstToken(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", ""
+	}
+	if s[0] == '"' || s[0] == '`' || s[0] == '[' {
+		closer := s[0]
+		if closer == '[' {
+			closer = ']'
+		}
+		if end := strings.IndexByte(s[1:], closer); end >= 0 {
+			return s[1 : end+1], s[end+2:]
+		}
+	}
+	end := strings.IndexFunc(s, func(r rune) bool { return unicode.IsSpace(r) || r == '(' })
+	if end < 0 {
+		return s, ""
+	}
+	return s[:end], s[end:]
+}
+
+func (defs sqliteDefs) column(name string) string {
+	for _, def := range defs {
+		if tok, _ := firstToken(def); tok == name {
+			return def
+		}
+	}
+	return ""
+}
+
+func (defs sqliteDefs) constraintName(typ string, cols []string) string {
+	for _, def := range defs {
+		tok, rest := firstToken(def)
+		if !strings.EqualFold(tok, "CONSTRAINT") {
+			continue
+		}
+		name, rest := firstToken(rest)
+		rest = strings.TrimSpace(rest)
+		if !strings.HasPrefix(strings.Join(strings.Fields(strings.ToUpper(rest)), " "), typ) {
+			continue
+		}
+		start := strings.Index(rest, "(")
+		end := strings.Index(rest, ")")
+		if start < 0 || end < start {
+			continue
+		}
+		defCols := sqlIdents(rest[start+1 : end])
+		if strings.Join(defCols, ",") == strings.Join(cols, ",") {
+			return name
+		}
+	}
+	return ""
+}
+
+// sqliteGeneratedExpr returns expression of "AS (expr)" in column definition.
+func sqliteGeneratedExpr(def string) string {
+	upper := strings.ToUpper(def)
+	i := strings.Index(upper, " AS ")
+	if i < 0 {
+		i = strings.Index(upper, " AS(")
+		if i < 0 {
+			return ""
+		}
+	}
+	start := strings.Index(def[i:], "(")
+	if start < 0 {
+		return ""
+	}
+	start += i
+	depth := 0
+	for j := start; j < len(def); j++ {
+		switch def[j] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return strings.TrimSpace(def[start+1 : j])
+			}
+		}
+	}
+	return ""
+}
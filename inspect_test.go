@@ -0,0 +1,97 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func openSQLite3(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// each connection has its own in-memory database
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestInspectSQLite3(t *testing.T) {
+	type Author struct {
+		Id    int64  `sqldb:"pk"`
+		Email string `sqldb:"unique"`
+	}
+	type Book struct {
+		Id       int64  `sqldb:"pk"`
+		AuthorId int64  `sqldb:"fk:author.id"`
+		Isbn     string `sqldb:"unique:isbn_title precision:20"`
+		Title    string `sqldb:"unique:isbn_title"`
+		Price    float64
+		Qty      int64 `sqldb:"default:1"`
+		Total    int64 `sqldb:"generated:'price * qty'"`
+		Note     *string
+	}
+	su := NewSQLUtil(NewTableParser(TableParserOptions{Default: true, Notnull: true}), SQLite3{})
+	db := openSQLite3(t)
+	if err := su.CreateTables(db, Author{}, Book{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`CREATE INDEX "ix_book_title" ON "book" ("title", "price")`); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	schemas, err := su.InspectSchema(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schemas) != 2 || schemas[0].Name != "author" || schemas[1].Name != "book" {
+		t.Fatalf("unexpected tables: %+v", schemas)
+	}
+	book := schemas[1]
+	col := func(name string) Column {
+		c, has := book.Col(name)
+		if !has {
+			t.Fatalf("column %s not found", name)
+		}
+		return c
+	}
+	if c := col("id"); !c.Primary || c.Type != "int64" || c.DBType != "INTEGER" || !c.Notnull {
+		t.Errorf("unexpected id column: %+v", c)
+	}
+	if c := col("author_id"); c.ForeignTable != "author" || c.ForeignCol != "id" {
+		t.Errorf("unexpected author_id column: %+v", c)
+	}
+	if c := col("isbn"); !c.Unique || c.UniqueName != "isbn_title" || c.Type != "string" {
+		t.Errorf("unexpected isbn column: %+v", c)
+	}
+	if c := col("qty"); !c.Default || c.DefaultVal != "1" {
+		t.Errorf("unexpected qty column: %+v", c)
+	}
+	if c := col("total"); c.Generated != "price * qty" || c.Virtual {
+		t.Errorf("unexpected total column: %+v", c)
+	}
+	if c := col("note"); c.Notnull || c.Default {
+		t.Errorf("unexpected note column: %+v", c)
+	}
+	if len(book.Indexes) != 1 || book.Indexes[0].Name != "ix_book_title" || len(book.Indexes[0].Cols) != 2 {
+		t.Errorf("unexpected indexes: %+v", book.Indexes)
+	}
+	if pk := book.Constraint(ConstraintPrimaryKey); len(pk) != 1 || pk[0].Name != "pk_book" {
+		t.Errorf("unexpected primary key: %+v", pk)
+	}
+	if len(book.Constraint(ConstraintPrimaryKey)) != 1 || len(book.Constraint(ConstraintUnique)) != 1 || len(book.Constraint(ConstraintForeignKey)) != 1 {
+		t.Errorf("unexpected constraints: %+v", book.Constraints)
+	}
+	if c, _ := schemas[0].Col("email"); !c.Unique || c.UniqueName != "uq_author_email" {
+		t.Errorf("unexpected email column: %+v", c)
+	}
+
+	if _, err = su.InspectSchema(ctx, db, "missing"); !errors.Is(err, ErrNoTable) {
+		t.Fatalf("expect no table error, got %v", err)
+	}
+}
@@ -0,0 +1,255 @@
+package sqldb
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrLockTimeout is returned if a lock can't be acquired before timeout.
+var ErrLockTimeout = errors.New("sqldb: lock timeout")
+
+// ErrLockLost is returned by Unlock if the lock was taken over by others
+// while held, such as a SQLite3 lock held longer than its lease.
+var ErrLockLost = errors.New("sqldb: lock lost")
+
+// lockRetryInterval is the interval of polling a lock held by others.
+const lockRetryInterval = 100 * time.Millisecond
+
+// defaultLockName is the lock shared by Migrator and CreateTablesContext, so
+// schema changes of both don't run concurrently.
+const (
+	defaultLockName    = "schema_migrations_lock"
+	defaultLockTimeout = time.Minute
+)
+
+// Locker is implemented by dialects supporting cross-process named locks.
+// Locks are held by the connection, timeout zero means trying only once.
+type Locker interface {
+	Lock(ctx context.Context, conn *sql.Conn, name string, timeout time.Duration) error
+	Unlock(ctx context.Context, conn *sql.Conn, name string) error
+}
+
+func (s *SQLUtil) locker() (Locker, error) {
+	locker, ok := s.dialect.(Locker)
+	if !ok {
+		return nil, fmt.Errorf("dialect %T doesn't support locking", s.dialect)
+	}
+	return locker, nil
+}
+
+// WithLock runs fn on a dedicated connection holding the named lock, the lock
+// is released after fn returns.
+func (s *SQLUtil) WithLock(ctx context.Context, db *sql.DB, name string, timeout time.Duration, fn func(conn *sql.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return s.withConnLock(ctx, conn, name, timeout, func() error {
+		return fn(conn)
+	})
+}
+
+// lockConn returns the connection of ex to hold a lock on, a *sql.Conn is used
+// as is and one is taken from a pool such as *sql.DB, release must be called
+// after use. conn is nil if ex can't provide one, such as *sql.Tx.
+func lockConn(ctx context.Context, ex interface{}) (conn *sql.Conn, release func(), err error) {
+	switch db := ex.(type) {
+	case *sql.Conn:
+		return db, func() {}, nil
+	case interface {
+		Conn(ctx context.Context) (*sql.Conn, error)
+	}:
+		conn, err = db.Conn(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		return conn, func() { conn.Close() }, nil
+	default:
+		return nil, func() {}, nil
+	}
+}
+
+func (s *SQLUtil) withConnLock(ctx context.Context, conn *sql.Conn, name string, timeout time.Duration, fn func() error) error {
+	locker, err := s.locker()
+	if err != nil {
+		return err
+	}
+	err = locker.Lock(ctx, conn, name, timeout)
+	if err != nil {
+		return err
+	}
+	err = fn()
+	// release even if ctx is done, the connection may be reused by pool
+	unlockErr := locker.Unlock(context.Background(), conn, name)
+	if err == nil {
+		err = unlockErr
+	}
+	return err
+}
+
+// pollLock calls try until it succeeds, timeout expires or ctx is done.
+func pollLock(ctx context.Context, name string, timeout time.Duration, try func() (bool, error)) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		ok, err := try()
+		if err != nil || ok {
+			return err
+		}
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			return fmt.Errorf("%w: %s", ErrLockTimeout, name)
+		}
+		if wait > lockRetryInterval {
+			wait = lockRetryInterval
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// advisoryLockKey hashes lock name to a Postgres advisory lock key.
+func advisoryLockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
+
+var _ Locker = Postgres{}
+
+func (Postgres) Lock(ctx context.Context, conn *sql.Conn, name string, timeout time.Duration) error {
+	key := advisoryLockKey(name)
+	return pollLock(ctx, name, timeout, func() (bool, error) {
+		var ok bool
+		err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&ok)
+		return ok, err
+	})
+}
+
+func (Postgres) Unlock(ctx context.Context, conn *sql.Conn, name string) error {
+	_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", advisoryLockKey(name))
+	return err
+}
+
+var _ Locker = MySQL{}
+
+func (MySQL) Lock(ctx context.Context, conn *sql.Conn, name string, timeout time.Duration) error {
+	var ok sql.NullInt64
+	seconds := int64(math.Ceil(timeout.Seconds()))
+	err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", name, seconds).Scan(&ok)
+	if err != nil {
+		return err
+	}
+	if !ok.Valid {
+		return fmt.Errorf("mysql: failed to get lock: %s", name)
+	}
+	if ok.Int64 != 1 {
+		return fmt.Errorf("%w: %s", ErrLockTimeout, name)
+	}
+	return nil
+}
+
+func (MySQL) Unlock(ctx context.Context, conn *sql.Conn, name string) error {
+	_, err := conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", name)
+	return err
+}
+
+var _ Locker = SQLite3{}
+
+// sqliteLockTable stores lock rows of SQLite3, a lock is held while its row
+// exists and is not older than the lease. Rows are owned by random ids, so
+// a lock taken over after its lease can't be released by the old holder.
+const sqliteLockTable = "sqldb_locks"
+
+const defaultSQLiteLockLease = 10 * time.Minute
+
+// sqliteLockKey identifies a lock held by a connection.
+type sqliteLockKey struct {
+	conn *sql.Conn
+	name string
+}
+
+// sqliteLockOwners maps held locks to the owner ids of their rows.
+var sqliteLockOwners sync.Map
+
+// isSQLiteBusy reports whether err is SQLITE_BUSY or SQLITE_LOCKED, the driver
+// isn't imported so it's checked by message.
+func isSQLiteBusy(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "database is locked") || strings.Contains(msg, "database table is locked")
+}
+
+// Lock holds the lock for at most LockLease, Unlock returns ErrLockLost if it
+// was taken over by others after that.
+func (d SQLite3) Lock(ctx context.Context, conn *sql.Conn, name string, timeout time.Duration) error {
+	lease := d.LockLease
+	if lease <= 0 {
+		lease = defaultSQLiteLockLease
+	}
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return err
+	}
+	owner := hex.EncodeToString(id[:])
+	err := pollLock(ctx, name, timeout, func() (bool, error) {
+		ok, err := sqliteTryLock(ctx, conn, name, owner, lease)
+		if err != nil && isSQLiteBusy(err) {
+			// database is written by others, retry later
+			return false, nil
+		}
+		return ok, err
+	})
+	if err != nil {
+		return err
+	}
+	sqliteLockOwners.Store(sqliteLockKey{conn: conn, name: name}, owner)
+	return nil
+}
+
+func sqliteTryLock(ctx context.Context, conn *sql.Conn, name, owner string, lease time.Duration) (bool, error) {
+	_, err := conn.ExecContext(ctx, fmt.Sprintf(
+		`CREATE TABLE IF NOT EXISTS "%s" (name TEXT PRIMARY KEY, owner TEXT NOT NULL, acquired_at DATETIME NOT NULL)`,
+		sqliteLockTable,
+	))
+	if err != nil {
+		return false, err
+	}
+	_, err = conn.ExecContext(ctx, fmt.Sprintf(
+		`DELETE FROM "%s" WHERE name = ? AND acquired_at < datetime('now', ?)`,
+		sqliteLockTable,
+	), name, fmt.Sprintf("-%d seconds", int64(math.Ceil(lease.Seconds()))))
+	if err != nil {
+		return false, err
+	}
+	res, err := conn.ExecContext(ctx, fmt.Sprintf(
+		`INSERT OR IGNORE INTO "%s" (name, owner, acquired_at) VALUES (?, ?, CURRENT_TIMESTAMP)`,
+		sqliteLockTable,
+	), name, owner)
+	n, err := ResultRowsAffected(res, err)
+	return n == 1, err
+}
+
+func (SQLite3) Unlock(ctx context.Context, conn *sql.Conn, name string) error {
+	owner, ok := sqliteLockOwners.LoadAndDelete(sqliteLockKey{conn: conn, name: name})
+	if !ok {
+		return fmt.Errorf("sqlite3: lock not held by connection: %s", name)
+	}
+	res, err := conn.ExecContext(ctx, fmt.Sprintf(`DELETE FROM "%s" WHERE name = ? AND owner = ?`, sqliteLockTable), name, owner)
+	n, err := ResultRowsAffected(res, err)
+	if err == nil && n == 0 {
+		err = fmt.Errorf("%w: %s", ErrLockLost, name)
+	}
+	return err
+}
@@ -0,0 +1,71 @@
+package sqldb
+
+import "reflect"
+
+// ColumnMapping supplies the settings of a field tag for fields that can't be
+// tagged, such as generated code. Empty values are left unset; Default,
+// Unique and Index are pointers because an empty value is meaningful for them.
+type ColumnMapping struct {
+	Col       string  `json:"col" yaml:"col" toml:"col"`
+	Type      string  `json:"type" yaml:"type" toml:"type"`
+	Precision string  `json:"precision" yaml:"precision" toml:"precision"`
+	DBType    string  `json:"dbtype" yaml:"dbtype" toml:"dbtype"`
+	PK        bool    `json:"pk" yaml:"pk" toml:"pk"`
+	AutoIncr  bool    `json:"autoincr" yaml:"autoincr" toml:"autoincr"`
+	Notnull   bool    `json:"notnull" yaml:"notnull" toml:"notnull"`
+	Default   *string `json:"default" yaml:"default" toml:"default"`
+	Unique    *string `json:"unique" yaml:"unique" toml:"unique"`
+	Index     *string `json:"index" yaml:"index" toml:"index"`
+	FK        string  `json:"fk" yaml:"fk" toml:"fk"`
+}
+
+func (m ColumnMapping) conds() []tagCond {
+	var conds []tagCond
+	add := func(name, val string, ok bool) {
+		if ok {
+			conds = append(conds, tagCond{Name: name, Val: val})
+		}
+	}
+	add("col", m.Col, m.Col != "")
+	add("type", m.Type, m.Type != "")
+	add("precision", m.Precision, m.Precision != "")
+	add("dbtype", m.DBType, m.DBType != "")
+	add("pk", "", m.PK)
+	add("autoincr", "", m.AutoIncr)
+	add("notnull", "", m.Notnull)
+	if m.Default != nil {
+		add("default", *m.Default, true)
+	}
+	if m.Unique != nil {
+		add("unique", *m.Unique, true)
+	}
+	if m.Index != nil {
+		add("index", *m.Index, true)
+	}
+	add("fk", m.FK, m.FK != "")
+	return conds
+}
+
+// TableMapping is the mapping document of a Go type, columns are keyed by
+// Go field name.
+type TableMapping struct {
+	Table   string                   `json:"table" yaml:"table" toml:"table"`
+	Columns map[string]ColumnMapping `json:"columns" yaml:"columns" toml:"columns"`
+}
+
+// TableMappings is keyed by Go type, either the full "import/path.Type", the
+// qualified "pkg.Type" or the bare "Type" name, looked up in that order.
+// Field tags win over mappings on conflict.
+type TableMappings map[string]TableMapping
+
+func (m TableMappings) lookup(t reflect.Type) (TableMapping, bool) {
+	if len(m) == 0 {
+		return TableMapping{}, false
+	}
+	for _, name := range []string{t.PkgPath() + "." + t.Name(), t.String(), t.Name()} {
+		if mapping, has := m[name]; has {
+			return mapping, true
+		}
+	}
+	return TableMapping{}, false
+}
@@ -0,0 +1,581 @@
+package sqldb
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrDirtyMigrations is returned if applied migrations have been modified or
+// are missing from the migration sources.
+var ErrDirtyMigrations = errors.New("sqldb: applied migrations modified or missing")
+
+// MigrateFunc applies or reverts a migration. The executor is a transaction
+// if the dialect supports transactional DDL.
+type MigrateFunc func(ctx context.Context, ex Executor) error
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      MigrateFunc
+	// Down is optional, migrations without it can't be reverted.
+	Down MigrateFunc
+	// Checksum detects modification of applied migrations, it's skipped if
+	// empty. SQLMigration computes it from the up SQL.
+	Checksum string
+}
+
+// SQLMigration creates a migration from SQL scripts, each script may contain
+// multiple statements, see SplitSQL. Empty down script means irreversible.
+func SQLMigration(version int64, name, up, down string) Migration {
+	sum := sha256.Sum256([]byte(up))
+	m := Migration{
+		Version:  version,
+		Name:     name,
+		Up:       sqlMigrateFunc(up),
+		Checksum: hex.EncodeToString(sum[:]),
+	}
+	if strings.TrimSpace(down) != "" {
+		m.Down = sqlMigrateFunc(down)
+	}
+	return m
+}
+
+func sqlMigrateFunc(script string) MigrateFunc {
+	stmts := SplitSQL(script)
+	return func(ctx context.Context, ex Executor) error {
+		for _, stmt := range stmts {
+			_, err := ex.ExecContext(ctx, stmt)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// MigrationsFromFS loads migrations from .sql files in dir of fsys, named as
+// VERSION_NAME.up.sql and VERSION_NAME.down.sql, down files are optional.
+func MigrationsFromFS(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	type scripts struct {
+		name     string
+		up, down string
+		hasUp    bool
+	}
+	var (
+		versions []int64
+		files    = make(map[int64]*scripts)
+	)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		base := strings.TrimSuffix(entry.Name(), ".sql")
+		var isUp bool
+		switch {
+		case strings.HasSuffix(base, ".up"):
+			isUp = true
+			base = strings.TrimSuffix(base, ".up")
+		case strings.HasSuffix(base, ".down"):
+			base = strings.TrimSuffix(base, ".down")
+		default:
+			return nil, fmt.Errorf("%s: migration file must end with .up.sql or .down.sql", entry.Name())
+		}
+		versionStr, name, _ := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(versionStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid migration version: %s", entry.Name(), versionStr)
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		s := files[version]
+		if s == nil {
+			s = &scripts{name: name}
+			files[version] = s
+			versions = append(versions, version)
+		} else if s.name != name {
+			return nil, fmt.Errorf("%s: conflicts with migration %d_%s", entry.Name(), version, s.name)
+		}
+		if isUp {
+			s.up = string(content)
+			s.hasUp = true
+		} else {
+			s.down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(versions))
+	for _, version := range versions {
+		s := files[version]
+		if !s.hasUp {
+			return nil, fmt.Errorf("migration %d_%s: missing up file", version, s.name)
+		}
+		migrations = append(migrations, SQLMigration(version, s.name, s.up, s.down))
+	}
+	return migrations, nil
+}
+
+// MigrationsFromDir loads migrations from .sql files in directory, see
+// MigrationsFromFS.
+func MigrationsFromDir(dir string) ([]Migration, error) {
+	return MigrationsFromFS(os.DirFS(dir), ".")
+}
+
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	// Modified reports checksum of applied migration has changed.
+	Modified bool
+	// Missing reports applied migration is no longer in the sources.
+	Missing bool
+}
+
+// Migrator applies migrations and records them in the history table.
+type Migrator struct {
+	su         *SQLUtil
+	db         TxBeginner
+	migrations []Migration
+
+	// Table is the history table, schema_migrations by default.
+	Table string
+	// LockName is the cross-process lock held by Up, Down and To if the
+	// dialect is a Locker, schema_migrations_lock by default, empty disables
+	// locking.
+	LockName string
+	// LockTimeout is the time waiting for the lock, one minute by default.
+	LockTimeout time.Duration
+}
+
+func NewMigrator(su *SQLUtil, db TxBeginner, migrations ...Migration) (*Migrator, error) {
+	migrations = append([]Migration(nil), migrations...)
+	sort.SliceStable(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	for i, m := range migrations {
+		if m.Up == nil {
+			return nil, fmt.Errorf("migration %d_%s: no up function", m.Version, m.Name)
+		}
+		if i > 0 && migrations[i-1].Version == m.Version {
+			return nil, fmt.Errorf("migration %d: duplicate version", m.Version)
+		}
+	}
+	return &Migrator{
+		su:          su,
+		db:          db,
+		migrations:  migrations,
+		Table:       "schema_migrations",
+		LockName:    defaultLockName,
+		LockTimeout: defaultLockTimeout,
+	}, nil
+}
+
+func (m *Migrator) historyTable() Table {
+	return Table{
+		Name: m.Table,
+		Cols: []Column{
+			{Name: "version", Type: "int64", Primary: true, Notnull: true},
+			{Name: "name", Type: "string", Precision: "255", Notnull: true},
+			{Name: "checksum", Type: "string", Precision: "64", Notnull: true},
+			{Name: "applied_at", Type: "time", Notnull: true},
+		},
+	}
+}
+
+func (m *Migrator) ensureHistory(ctx context.Context) error {
+	createSQL, err := m.su.CreateTableSQL(m.historyTable())
+	if err != nil {
+		return err
+	}
+	_, err = m.db.ExecContext(ctx, createSQL)
+	return err
+}
+
+type appliedMigration struct {
+	version   int64
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+func (m *Migrator) applied(ctx context.Context) ([]appliedMigration, error) {
+	err := m.ensureHistory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := m.db.QueryContext(ctx, fmt.Sprintf(
+		"SELECT version, name, checksum, applied_at FROM %s ORDER BY version",
+		m.su.EscapeName(m.Table),
+	))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var applied []appliedMigration
+	for rows.Next() {
+		var a appliedMigration
+		err = rows.Scan(&a.version, &a.name, &a.checksum, timeScanner{&a.appliedAt})
+		if err != nil {
+			return nil, err
+		}
+		applied = append(applied, a)
+	}
+	return applied, rows.Err()
+}
+
+// timeLayouts are layouts of times returned as text.
+var timeLayouts = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	time.RFC3339Nano,
+}
+
+// timeScanner scans time returned as time.Time, or as text by drivers not
+// parsing it such as go-sql-driver/mysql without parseTime.
+type timeScanner struct {
+	t *time.Time
+}
+
+func (s timeScanner) Scan(src interface{}) error {
+	var text string
+	switch src := src.(type) {
+	case time.Time:
+		*s.t = src
+		return nil
+	case []byte:
+		text = string(src)
+	case string:
+		text = src
+	default:
+		return fmt.Errorf("sqldb: can't scan %T into time", src)
+	}
+	for _, layout := range timeLayouts {
+		t, err := time.Parse(layout, text)
+		if err == nil {
+			*s.t = t
+			return nil
+		}
+	}
+	return fmt.Errorf("sqldb: invalid time %q", text)
+}
+
+func (m *Migrator) migration(version int64) (Migration, bool) {
+	i := sort.Search(len(m.migrations), func(i int) bool {
+		return m.migrations[i].Version >= version
+	})
+	if i < len(m.migrations) && m.migrations[i].Version == version {
+		return m.migrations[i], true
+	}
+	return Migration{}, false
+}
+
+// Status returns status of all known and applied migrations, ordered by
+// version.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	appliedSet := make(map[int64]appliedMigration, len(applied))
+	for _, a := range applied {
+		appliedSet[a.version] = a
+	}
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		st := MigrationStatus{Version: mig.Version, Name: mig.Name}
+		if a, ok := appliedSet[mig.Version]; ok {
+			st.Applied = true
+			st.AppliedAt = a.appliedAt
+			st.Modified = mig.Checksum != "" && a.checksum != "" && mig.Checksum != a.checksum
+		}
+		statuses = append(statuses, st)
+	}
+	for _, a := range applied {
+		if _, ok := m.migration(a.version); !ok {
+			statuses = append(statuses, MigrationStatus{
+				Version:   a.version,
+				Name:      a.name,
+				Applied:   true,
+				AppliedAt: a.appliedAt,
+				Missing:   true,
+			})
+		}
+	}
+	sort.SliceStable(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// check returns statuses after verifying no applied migration is modified
+// or missing.
+func (m *Migrator) check(ctx context.Context) ([]MigrationStatus, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var errs []error
+	for _, st := range statuses {
+		if st.Modified {
+			errs = append(errs, fmt.Errorf("%w: %d_%s modified", ErrDirtyMigrations, st.Version, st.Name))
+		}
+		if st.Missing {
+			errs = append(errs, fmt.Errorf("%w: %d_%s missing", ErrDirtyMigrations, st.Version, st.Name))
+		}
+	}
+	return statuses, errors.Join(errs...)
+}
+
+// locked runs fn with a migrator holding the lock on a dedicated connection.
+func (m *Migrator) locked(ctx context.Context, fn func(m *Migrator) error) error {
+	if m.LockName == "" {
+		return fn(m)
+	}
+	if _, ok := m.su.dialect.(Locker); !ok {
+		return fn(m)
+	}
+	conn, release, err := lockConn(ctx, m.db)
+	if err != nil {
+		return err
+	}
+	defer release()
+	if conn == nil {
+		return fmt.Errorf("can't lock on %T", m.db)
+	}
+	lm := *m
+	lm.db = conn
+	return m.su.withConnLock(ctx, conn, m.LockName, m.LockTimeout, func() error {
+		return fn(&lm)
+	})
+}
+
+// Up applies all pending migrations.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.To(ctx, -1)
+}
+
+// Down reverts the last n applied migrations, n must be positive.
+func (m *Migrator) Down(ctx context.Context, n int) error {
+	if n <= 0 {
+		return fmt.Errorf("invalid number of migrations to revert: %d", n)
+	}
+	return m.locked(ctx, func(m *Migrator) error {
+		return m.down(ctx, n)
+	})
+}
+
+func (m *Migrator) down(ctx context.Context, n int) error {
+	statuses, err := m.check(ctx)
+	if err != nil {
+		return err
+	}
+	for i := len(statuses) - 1; i >= 0 && n > 0; i-- {
+		if !statuses[i].Applied {
+			continue
+		}
+		err = m.revert(ctx, statuses[i].Version)
+		if err != nil {
+			return err
+		}
+		n--
+	}
+	return nil
+}
+
+// To applies pending migrations up to version and reverts applied ones after
+// it, version -1 means the latest.
+func (m *Migrator) To(ctx context.Context, version int64) error {
+	return m.locked(ctx, func(m *Migrator) error {
+		return m.to(ctx, version)
+	})
+}
+
+func (m *Migrator) to(ctx context.Context, version int64) error {
+	statuses, err := m.check(ctx)
+	if err != nil {
+		return err
+	}
+	if version >= 0 {
+		if _, ok := m.migration(version); !ok && version != 0 {
+			return fmt.Errorf("migration %d: not found", version)
+		}
+		for i := len(statuses) - 1; i >= 0; i-- {
+			if statuses[i].Applied && statuses[i].Version > version {
+				err = m.revert(ctx, statuses[i].Version)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, st := range statuses {
+		if st.Applied || (version >= 0 && st.Version > version) {
+			continue
+		}
+		err = m.apply(ctx, st.Version)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) apply(ctx context.Context, version int64) error {
+	mig, _ := m.migration(version)
+	err := m.run(ctx, func(ex Executor) error {
+		err := mig.Up(ctx, ex)
+		if err != nil {
+			return err
+		}
+		_, err = ex.ExecContext(ctx, fmt.Sprintf(
+			"INSERT INTO %s (version, name, checksum, applied_at) VALUES (%s, %s, %s, CURRENT_TIMESTAMP)",
+			m.su.EscapeName(m.Table), m.su.placeholder(1), m.su.placeholder(2), m.su.placeholder(3),
+		), mig.Version, mig.Name, mig.Checksum)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("migration %d_%s: up: %w", mig.Version, mig.Name, err)
+	}
+	return nil
+}
+
+func (m *Migrator) revert(ctx context.Context, version int64) error {
+	mig, _ := m.migration(version)
+	if mig.Down == nil {
+		return fmt.Errorf("migration %d_%s: irreversible", mig.Version, mig.Name)
+	}
+	err := m.run(ctx, func(ex Executor) error {
+		err := mig.Down(ctx, ex)
+		if err != nil {
+			return err
+		}
+		_, err = ex.ExecContext(ctx, fmt.Sprintf(
+			"DELETE FROM %s WHERE version = %s",
+			m.su.EscapeName(m.Table), m.su.placeholder(1),
+		), mig.Version)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("migration %d_%s: down: %w", mig.Version, mig.Name, err)
+	}
+	return nil
+}
+
+// run executes fn in a transaction if the dialect supports transactional DDL.
+func (m *Migrator) run(ctx context.Context, fn func(ex Executor) error) (err error) {
+	if !dialectFeatures(m.su.dialect).TransactionalDDL {
+		return fn(m.db)
+	}
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer TxDone(tx, &err)
+	return fn(tx)
+}
+
+// SplitSQL splits script into statements by semicolons outside of quoted
+// strings, quoted identifiers, comments and Postgres dollar-quoted bodies.
+// Statements containing only comments are dropped.
+func SplitSQL(script string) []string {
+	var (
+		stmts      []string
+		start      int
+		hasContent bool
+	)
+	for i := 0; i < len(script); {
+		c := script[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			hasContent = true
+			i++
+			for i < len(script) {
+				if script[i] == c {
+					// doubled quote is an escaped one
+					if i+1 < len(script) && script[i+1] == c {
+						i += 2
+						continue
+					}
+					break
+				}
+				i++
+			}
+			i++
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += end + 1
+			}
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += end + 4
+			}
+		case c == '$':
+			hasContent = true
+			tag := dollarQuoteTag(script[i:])
+			if tag == "" {
+				i++
+				continue
+			}
+			end := strings.Index(script[i+len(tag):], tag)
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += len(tag) + end + len(tag)
+			}
+		case c == ';':
+			if hasContent {
+				stmts = append(stmts, strings.TrimSpace(script[start:i+1]))
+			}
+			i++
+			start = i
+			hasContent = false
+		default:
+			if c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+				hasContent = true
+			}
+			i++
+		}
+	}
+	if hasContent {
+		stmts = append(stmts, strings.TrimSpace(script[start:]))
+	}
+	return stmts
+}
+
+// dollarQuoteTag returns the leading $tag$ of s, or empty if it isn't one.
+func dollarQuoteTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '$':
+			return s[:i+1]
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || (i > 1 && c >= '0' && c <= '9'):
+		default:
+			return ""
+		}
+	}
+	return ""
+}
@@ -0,0 +1,309 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestSplitSQL(t *testing.T) {
+	script := `-- leading comment
+CREATE TABLE a (s TEXT DEFAULT 'x;y''z');
+/* block; comment */
+INSERT INTO "we;ird" VALUES (1);
+CREATE FUNCTION f() RETURNS TRIGGER AS $body$
+BEGIN
+    NEW.a = 1;
+END;
+$body$ LANGUAGE plpgsql;
+-- trailing comment;
+SELECT $1`
+	stmts := SplitSQL(script)
+	expect := []string{
+		"-- leading comment\nCREATE TABLE a (s TEXT DEFAULT 'x;y''z');",
+		"/* block; comment */\nINSERT INTO \"we;ird\" VALUES (1);",
+		"CREATE FUNCTION f() RETURNS TRIGGER AS $body$\nBEGIN\n    NEW.a = 1;\nEND;\n$body$ LANGUAGE plpgsql;",
+		"-- trailing comment;\nSELECT $1",
+	}
+	if !reflect.DeepEqual(stmts, expect) {
+		t.Fatalf("unexpected statements: %q", stmts)
+	}
+}
+
+func TestTimeScanner(t *testing.T) {
+	expect := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, src := range []interface{}{
+		expect,
+		[]byte("2024-01-02 03:04:05"),
+		"2024-01-02 03:04:05.000000+00:00",
+		"2024-01-02T03:04:05Z",
+	} {
+		var got time.Time
+		if err := (timeScanner{&got}).Scan(src); err != nil {
+			t.Fatal(err)
+		}
+		if !got.Equal(expect) {
+			t.Errorf("%v: expect %s, but got %s", src, expect, got)
+		}
+	}
+	var got time.Time
+	if err := (timeScanner{&got}).Scan("yesterday"); err == nil {
+		t.Error("expect error for invalid time")
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/1_users.up.sql":      {Data: []byte("CREATE TABLE users (id INTEGER PRIMARY KEY);\nCREATE INDEX ix_users ON users (id);")},
+		"migrations/1_users.down.sql":    {Data: []byte("DROP TABLE users;")},
+		"migrations/2_posts.up.sql":      {Data: []byte("CREATE TABLE posts (id INTEGER PRIMARY KEY);")},
+		"migrations/2_posts.down.sql":    {Data: []byte("DROP TABLE posts;")},
+		"migrations/README.md":           {Data: []byte("ignored")},
+		"migrations/3_comments.up.sql":   {Data: []byte("CREATE TABLE comments (id INTEGER PRIMARY KEY);")},
+		"migrations/3_comments.down.sql": {Data: []byte("DROP TABLE comments;")},
+	}
+	migrations, err := MigrationsFromFS(fsys, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seeded bool
+	migrations = append(migrations, Migration{
+		Version: 4,
+		Name:    "seed",
+		Up: func(ctx context.Context, ex Executor) error {
+			seeded = true
+			_, err := ex.ExecContext(ctx, "INSERT INTO users (id) VALUES (1)")
+			return err
+		},
+	})
+
+	ctx := context.Background()
+	db := openSQLite3(t)
+	su := NewSQLUtil(NewTableParser(), SQLite3{})
+	m, err := NewMigrator(su, db, migrations...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tableNames := func() []string {
+		names, err := SQLite3{}.TableNames(ctx, db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return names
+	}
+	applied := func() []int64 {
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var versions []int64
+		for _, st := range statuses {
+			if st.Applied {
+				if st.AppliedAt.IsZero() {
+					t.Fatal("applied time should be recorded")
+				}
+				versions = append(versions, st.Version)
+			}
+		}
+		return versions
+	}
+
+	if err = m.To(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if v := applied(); !reflect.DeepEqual(v, []int64{1, 2}) {
+		t.Fatal("unexpected applied versions", v)
+	}
+	if err = m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if v := applied(); !reflect.DeepEqual(v, []int64{1, 2, 3, 4}) || !seeded {
+		t.Fatal("unexpected applied versions", v)
+	}
+	if err = m.Down(ctx, 1); err == nil {
+		t.Fatal("irreversible migration should fail to revert")
+	}
+	if err = m.To(ctx, 3); err == nil {
+		t.Fatal("irreversible migration should fail to revert")
+	}
+
+	// failed migration is rolled back with its history record
+	m.migrations[3].Down = func(ctx context.Context, ex Executor) error {
+		_, err := ex.ExecContext(ctx, "DELETE FROM users")
+		if err != nil {
+			return err
+		}
+		return errors.New("failed")
+	}
+	if err = m.Down(ctx, 1); err == nil {
+		t.Fatal("expect migration failure")
+	}
+	var count int
+	if err = db.QueryRow("SELECT COUNT(*) FROM users").Scan(&count); err != nil || count != 1 {
+		t.Fatal("failed migration should be rolled back", count, err)
+	}
+	m.migrations[3].Down = func(ctx context.Context, ex Executor) error {
+		_, err := ex.ExecContext(ctx, "DELETE FROM users")
+		return err
+	}
+	for _, n := range []int{0, -1} {
+		if err = m.Down(ctx, n); err == nil {
+			t.Fatal("expect error for invalid count", n)
+		}
+	}
+	if err = m.Down(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if v := applied(); !reflect.DeepEqual(v, []int64{1, 2}) {
+		t.Fatal("unexpected applied versions", v)
+	}
+	if names := tableNames(); !reflect.DeepEqual(names, []string{"posts", "schema_migrations", "users"}) {
+		t.Fatal("unexpected tables", names)
+	}
+
+	fsys["migrations/2_posts.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE posts (id BIGINT PRIMARY KEY);")}
+	modified, err := MigrationsFromFS(fsys, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m2, err := NewMigrator(su, db, modified[0], modified[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = m2.Up(ctx); !errors.Is(err, ErrDirtyMigrations) {
+		t.Fatal("modified migration should be detected", err)
+	}
+	m3, err := NewMigrator(su, db, migrations[1:]...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses, err := m3.Status(ctx)
+	if err != nil || !statuses[0].Missing || statuses[0].Version != 1 {
+		t.Fatal("missing migration should be detected", statuses, err)
+	}
+	if err = m3.Up(ctx); !errors.Is(err, ErrDirtyMigrations) {
+		t.Fatal("missing migration should be detected", err)
+	}
+
+	if err = m.To(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if names := tableNames(); !reflect.DeepEqual(names, []string{"schema_migrations"}) {
+		t.Fatal("unexpected tables", names)
+	}
+
+	if _, err = MigrationsFromFS(fstest.MapFS{"1_a.down.sql": {}}, "."); err == nil {
+		t.Fatal("migration without up file should fail")
+	}
+	if _, err = NewMigrator(su, db, migrations[0], migrations[0]); err == nil {
+		t.Fatal("duplicate version should fail")
+	}
+}
+
+func TestMigratorLock(t *testing.T) {
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "lock.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	su := NewSQLUtil(NewTableParser(), SQLite3{})
+	m, err := NewMigrator(su, db, SQLMigration(1, "users", "CREATE TABLE users (id INTEGER PRIMARY KEY);", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.LockTimeout = 150 * time.Millisecond
+	err = su.WithLock(ctx, db, m.LockName, 0, func(conn *sql.Conn) error {
+		return m.Up(ctx)
+	})
+	if !errors.Is(err, ErrLockTimeout) {
+		t.Fatal("expect lock timeout", err)
+	}
+	if err = m.Up(ctx); err != nil {
+		t.Fatal("lock should be released", err)
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil || len(statuses) != 1 || !statuses[0].Applied {
+		t.Fatal("migration should be applied", statuses, err)
+	}
+}
+
+func TestSQLite3LockExpire(t *testing.T) {
+	dsn := "file:" + filepath.Join(t.TempDir(), "lock.db")
+	db, err := sql.Open("sqlite3", dsn+"?_busy_timeout=10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	d := SQLite3{LockLease: time.Minute}
+	conns := make([]*sql.Conn, 3)
+	for i := range conns {
+		if conns[i], err = db.Conn(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = d.Lock(ctx, conns[0], "stale", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err = d.Lock(ctx, conns[1], "stale", 0); !errors.Is(err, ErrLockTimeout) {
+		t.Fatal("fresh lock should be held", err)
+	}
+	// holder exceeds the lease or crashed
+	_, err = conns[0].ExecContext(ctx, `UPDATE sqldb_locks SET acquired_at = datetime('now', '-2 minutes')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = d.Lock(ctx, conns[1], "stale", 0); err != nil {
+		t.Fatal("stale lock should be taken over", err)
+	}
+	if err = d.Unlock(ctx, conns[0], "stale"); !errors.Is(err, ErrLockLost) {
+		t.Fatal("old holder should lose the lock", err)
+	}
+	if err = d.Lock(ctx, conns[2], "stale", 0); !errors.Is(err, ErrLockTimeout) {
+		t.Fatal("lock taken over should be kept", err)
+	}
+	if err = d.Unlock(ctx, conns[1], "stale"); err != nil {
+		t.Fatal(err)
+	}
+	if err = d.Lock(ctx, conns[2], "stale", 0); err != nil {
+		t.Fatal("released lock should be acquired", err)
+	}
+	if err = d.Unlock(ctx, conns[2], "stale"); err != nil {
+		t.Fatal(err)
+	}
+	for _, conn := range conns {
+		conn.Close()
+	}
+	su := NewSQLUtil(NewTableParser(), d)
+
+	// exclusive transaction of another process makes the database busy
+	other, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	conn, err := other.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err = conn.ExecContext(ctx, "BEGIN EXCLUSIVE"); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		conn.ExecContext(ctx, "ROLLBACK")
+	}()
+	err = su.WithLock(ctx, db, "busy", time.Second, func(*sql.Conn) error { return nil })
+	if err != nil {
+		t.Fatal("busy database should be retried", err)
+	}
+}
@@ -0,0 +1,80 @@
+package sqldb
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"unicode/utf8"
+)
+
+// NamingStrategy names constraints and indexes not named by tags, and
+// triggers keeping updated columns.
+type NamingStrategy interface {
+	PrimaryKey(table string) string
+	ForeignKey(table string, cols []string) string
+	Unique(table string, cols []string) string
+	Index(table string, cols []string) string
+	Trigger(table, col string) string
+}
+
+// DefaultNaming names constraints and indexes as pk_TABLE, fk_TABLE_COLUMNS,
+// uq_TABLE_COLUMNS and ix_TABLE_COLUMNS, columns are joined by underscore.
+// Triggers are named TABLE_set_COLUMN.
+type DefaultNaming struct{}
+
+var _ NamingStrategy = DefaultNaming{}
+
+func (DefaultNaming) PrimaryKey(table string) string {
+	return "pk_" + table
+}
+
+func (DefaultNaming) ForeignKey(table string, cols []string) string {
+	return "fk_" + table + "_" + strings.Join(cols, "_")
+}
+
+func (DefaultNaming) Unique(table string, cols []string) string {
+	return "uq_" + table + "_" + strings.Join(cols, "_")
+}
+
+func (DefaultNaming) Index(table string, cols []string) string {
+	return "ix_" + table + "_" + strings.Join(cols, "_")
+}
+
+func (DefaultNaming) Trigger(table, col string) string {
+	return table + "_set_" + col
+}
+
+// SetNaming sets the strategy naming constraints and indexes, DefaultNaming
+// by default. If it's nil, constraints not named by tags are left to database
+// and indexes and triggers are named by DefaultNaming.
+func (s *SQLUtil) SetNaming(naming NamingStrategy) {
+	s.naming = naming
+}
+
+// identifier shortens name exceeding the identifier length limit of dialect,
+// the kept prefix is followed by a hash of the full name so shortened names
+// don't collide.
+func (s *SQLUtil) identifier(name string) string {
+	limit := dialectFeatures(s.dialect).MaxIdentifierLen
+	if limit <= 0 || len(name) <= limit {
+		return name
+	}
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	suffix := fmt.Sprintf("_%08x", h.Sum32())
+	prefix := name[:limit-len(suffix)]
+	for len(prefix) > 0 && !utf8.ValidString(prefix) {
+		prefix = prefix[:len(prefix)-1]
+	}
+	return prefix + suffix
+}
+
+// checkIdentifier fails if name exceeds the identifier length limit of
+// dialect, used for names can't be shortened such as tables and columns.
+func (s *SQLUtil) checkIdentifier(name string) error {
+	limit := dialectFeatures(s.dialect).MaxIdentifierLen
+	if limit > 0 && len(name) > limit {
+		return fmt.Errorf("identifier %s exceeds %d bytes", name, limit)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package sqldb
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Register parses models eagerly and adds them to the registered set. It
+// fails without registering anything if two types map to the same table,
+// a unique constraint name is shared by different tables, or a foreign key
+// references a table or column no registered model defines. Generated names
+// are checked by SQLUtil.CheckNames.
+func (p *TableParser) Register(models ...interface{}) error {
+	var types []reflect.Type
+	for _, m := range models {
+		t, err := p.StructTable(m)
+		if err != nil {
+			return err
+		}
+		types = append(types, t.Type)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	registered := make([]reflect.Type, len(p.registered), len(p.registered)+len(types))
+	copy(registered, p.registered)
+	for _, t := range types {
+		var has bool
+		for _, r := range registered {
+			if r == t {
+				has = true
+				break
+			}
+		}
+		if !has {
+			registered = append(registered, t)
+		}
+	}
+	tables := make([]Table, 0, len(registered))
+	for _, t := range registered {
+		tables = append(tables, p.tables[t])
+	}
+	if err := p.checkTables(tables); err != nil {
+		return err
+	}
+	p.registered = registered
+	return nil
+}
+
+func (p *TableParser) checkTables(tables []Table) error {
+	var (
+		errs        []error
+		names       = make(map[string]Table)
+		constraints = make(map[string]string)
+	)
+	for _, t := range tables {
+		if prev, has := names[t.Name]; has {
+			errs = append(errs, fmt.Errorf("table %s: defined by both %s and %s", t.Name, prev.Type, t.Type))
+			continue
+		}
+		names[t.Name] = t
+		for _, c := range t.Cols {
+			if !c.Unique {
+				continue
+			}
+			for _, name := range strings.Split(c.UniqueName, ",") {
+				if name == "" {
+					continue
+				}
+				if prev, has := constraints[name]; has && prev != t.Name {
+					errs = append(errs, fmt.Errorf("constraint %s: used by both %s and %s", name, prev, t.Name))
+					continue
+				}
+				constraints[name] = t.Name
+			}
+		}
+	}
+	for _, t := range tables {
+		for _, c := range t.Cols {
+			if c.ForeignTable == "" {
+				continue
+			}
+			ft, has := names[c.ForeignTable]
+			if !has {
+				errs = append(errs, fmt.Errorf("%s.%s: foreign table %s is not registered", t.Name, c.Name, c.ForeignTable))
+				continue
+			}
+			if _, has = ft.Col(c.ForeignCol); !has {
+				errs = append(errs, fmt.Errorf("%s.%s: foreign column %s.%s is not defined", t.Name, c.Name, c.ForeignTable, c.ForeignCol))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CheckNames fails if the final constraint and index names of tables collide,
+// such as an explicit name equal to a generated one, or long names shortened
+// to the same identifier. Names are checked per schema, except those the
+// dialect scopes to tables. Register can't check them since they depend on
+// the dialect and naming strategy, CreateTablesContext checks its tables.
+func (s *SQLUtil) CheckNames(tables ...Table) error {
+	var (
+		errs   []error
+		owners = make(map[string]string)
+		scoped = dialectFeatures(s.dialect).TableScopedNames
+	)
+	use := func(table, kind, name string) {
+		if name == "" {
+			return
+		}
+		key := name
+		if scoped && kind != ConstraintForeignKey {
+			key = table + "." + name
+		}
+		owner := kind + " of " + table
+		if prev, has := owners[key]; has {
+			errs = append(errs, fmt.Errorf("name %s: used by both %s and %s", name, prev, owner))
+			return
+		}
+		owners[key] = owner
+	}
+	for _, t := range tables {
+		for _, c := range s.tableConstraints(t) {
+			use(t.Name, c.Type, c.Name)
+		}
+		for _, index := range s.tableIndexes(t) {
+			use(t.Name, "INDEX", index.Name)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Tables returns the registered tables in dependency order, referenced tables
+// come before the tables referencing them. Tables in a foreign key cycle keep
+// their registration order.
+func (p *TableParser) Tables() []Table {
+	p.mu.RLock()
+	tables := make([]Table, 0, len(p.registered))
+	for _, t := range p.registered {
+		tables = append(tables, p.tables[t])
+	}
+	p.mu.RUnlock()
+	return sortTables(tables)
+}
+
+func sortTables(tables []Table) []Table {
+	sorted := make([]Table, 0, len(tables))
+	done := make(map[string]bool, len(tables))
+	remains := append([]Table(nil), tables...)
+	present := make(map[string]bool, len(tables))
+	for _, t := range tables {
+		present[t.Name] = true
+	}
+	ready := func(t Table) bool {
+		for _, c := range t.Cols {
+			if c.ForeignTable != "" && c.ForeignTable != t.Name && present[c.ForeignTable] && !done[c.ForeignTable] {
+				return false
+			}
+		}
+		return true
+	}
+	// cyclic reports whether t references itself through remaining tables.
+	cyclic := func(t Table) bool {
+		var (
+			visited = make(map[string]bool)
+			visit   func(name string) bool
+		)
+		visit = func(name string) bool {
+			for _, r := range remains {
+				if r.Name != name {
+					continue
+				}
+				for _, c := range r.Cols {
+					if c.ForeignTable == "" || c.ForeignTable == r.Name || done[c.ForeignTable] {
+						continue
+					}
+					if c.ForeignTable == t.Name {
+						return true
+					}
+					if !visited[c.ForeignTable] {
+						visited[c.ForeignTable] = true
+						if visit(c.ForeignTable) {
+							return true
+						}
+					}
+				}
+			}
+			return false
+		}
+		return visit(t.Name)
+	}
+	for len(remains) > 0 {
+		next := -1
+		for i, t := range remains {
+			if ready(t) {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			next = 0
+			for i, t := range remains {
+				if cyclic(t) {
+					next = i
+					break
+				}
+			}
+		}
+		t := remains[next]
+		sorted = append(sorted, t)
+		done[t.Name] = true
+		remains = append(remains[:next], remains[next+1:]...)
+	}
+	return sorted
+}
@@ -0,0 +1,447 @@
+package sqldb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	ChangeCreateTable    = "create table"
+	ChangeDropConstraint = "drop constraint"
+	ChangeDropIndex      = "drop index"
+	ChangeAddColumn      = "add column"
+	ChangeColumnType     = "change column type"
+	ChangeColumnNull     = "change column nullability"
+	ChangeColumnDefault  = "change column default"
+	ChangeRebuildTable   = "rebuild table"
+	ChangeDropColumn     = "drop column"
+	ChangeAddConstraint  = "add constraint"
+	ChangeAddIndex       = "add index"
+)
+
+// changeOrder is the order changes are applied, constraints and indexes are
+// dropped before columns are changed and added after.
+var changeOrder = []string{
+	ChangeCreateTable,
+	ChangeDropConstraint,
+	ChangeDropIndex,
+	ChangeAddColumn,
+	ChangeColumnType,
+	ChangeColumnNull,
+	ChangeColumnDefault,
+	ChangeRebuildTable,
+	ChangeDropColumn,
+	ChangeAddConstraint,
+	ChangeAddIndex,
+}
+
+// SchemaChange is a change bringing the live schema to the model. Name is the
+// column, index or constraint changed, Detail describes the change.
+// Destructive changes may lose data, unsafe changes may fail on existing rows
+// such as adding a NOT NULL column without default.
+type SchemaChange struct {
+	Kind        string
+	Table       string
+	Name        string
+	Detail      string
+	Statements  []string
+	Destructive bool
+	Unsafe      bool
+}
+
+// SchemaDiff is ordered schema changes.
+type SchemaDiff []SchemaChange
+
+func (d SchemaDiff) filter(fn func(c SchemaChange) bool) SchemaDiff {
+	var changes SchemaDiff
+	for _, c := range d {
+		if fn(c) {
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+// Safe returns changes which neither lose data nor fail on existing rows.
+func (d SchemaDiff) Safe() SchemaDiff {
+	return d.filter(func(c SchemaChange) bool { return !c.Destructive && !c.Unsafe })
+}
+
+// Destructive returns changes which may lose data.
+func (d SchemaDiff) Destructive() SchemaDiff {
+	return d.filter(func(c SchemaChange) bool { return c.Destructive })
+}
+
+// Unsafe returns changes which may fail on existing rows.
+func (d SchemaDiff) Unsafe() SchemaDiff {
+	return d.filter(func(c SchemaChange) bool { return c.Unsafe })
+}
+
+// Statements returns statements of all changes in order.
+func (d SchemaDiff) Statements() []string {
+	var stmts []string
+	for _, c := range d {
+		stmts = append(stmts, c.Statements...)
+	}
+	return stmts
+}
+
+func (d SchemaDiff) sort() {
+	order := make(map[string]int, len(changeOrder))
+	for i, kind := range changeOrder {
+		order[kind] = i
+	}
+	sort.SliceStable(d, func(i, j int) bool {
+		return order[d[i].Kind] < order[d[j].Kind]
+	})
+}
+
+// DiffSchema compares tables with the live schema read by q, tables should be
+// in dependency order such as TableParser.Tables.
+func (s *SQLUtil) DiffSchema(ctx context.Context, q Queryer, tables ...Table) (SchemaDiff, error) {
+	inspector, err := s.schemaInspector()
+	if err != nil {
+		return nil, err
+	}
+	var diff SchemaDiff
+	for _, table := range tables {
+		live, err := inspector.InspectTable(ctx, q, table.Name)
+		var changes SchemaDiff
+		switch {
+		case errors.Is(err, ErrNoTable):
+			changes, err = s.createTableChanges(table)
+		case err == nil:
+			changes, err = s.DiffTable(table, live)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", table.Name, err)
+		}
+		diff = append(diff, changes...)
+	}
+	diff.sort()
+	return diff, nil
+}
+
+func (s *SQLUtil) createTableChanges(table Table) (SchemaDiff, error) {
+	createSQL, err := s.CreateTableSQL(table)
+	if err != nil {
+		return nil, err
+	}
+	return SchemaDiff{{
+		Kind:       ChangeCreateTable,
+		Table:      table.Name,
+		Name:       table.Name,
+		Statements: append([]string{createSQL}, s.CreateIndexSQL(table)...),
+	}}, nil
+}
+
+type typeInspector interface {
+	inspectedType(dbType string) (typ, precision string)
+}
+
+func (s *SQLUtil) sameType(a, b string) bool {
+	if inspector, ok := s.dialect.(typeInspector); ok {
+		at, ap := inspector.inspectedType(a)
+		bt, bp := inspector.inspectedType(b)
+		return at == bt && ap == bp
+	}
+	return strings.EqualFold(strings.Join(strings.Fields(a), " "), strings.Join(strings.Fields(b), " "))
+}
+
+// normalizeDefault strips parentheses, casts and quotes of default
+// expression for comparison.
+func normalizeDefault(v string) string {
+	v = strings.TrimSpace(v)
+	for len(v) >= 2 && v[0] == '(' && v[len(v)-1] == ')' {
+		v = strings.TrimSpace(v[1 : len(v)-1])
+	}
+	if i := strings.LastIndex(v, "::"); i > 0 && !strings.Contains(v[i:], "'") {
+		v = v[:i]
+	}
+	v = strings.TrimPrefix(v, "E'")
+	v = strings.Trim(v, "'")
+	switch strings.ToUpper(v) {
+	case "FALSE":
+		return "0"
+	case "TRUE":
+		return "1"
+	case "NOW()", "CURRENT_TIMESTAMP()", "CURRENT_TIMESTAMP":
+		return "CURRENT_TIMESTAMP"
+	}
+	return v
+}
+
+func sameConstraint(a, b Constraint) bool {
+	return a.Type == b.Type &&
+		strings.Join(a.Cols, ",") == strings.Join(b.Cols, ",") &&
+		a.ForeignTable == b.ForeignTable &&
+		strings.Join(a.ForeignCols, ",") == strings.Join(b.ForeignCols, ",")
+}
+
+func sameIndex(a, b Index) bool {
+	return a.Name == b.Name && a.Unique == b.Unique && strings.Join(a.Cols, ",") == strings.Join(b.Cols, ",")
+}
+
+// DiffTable compares model table with its live schema.
+func (s *SQLUtil) DiffTable(table Table, live TableSchema) (SchemaDiff, error) {
+	var (
+		diff    SchemaDiff
+		style   = dialectFeatures(s.dialect).Alter
+		tname   = s.EscapeName(table.Name)
+		rebuild []string
+		dropped bool
+		unsafe  bool
+	)
+	add := func(kind, name, detail string, destructive bool, stmts ...string) {
+		diff = append(diff, SchemaChange{
+			Kind:        kind,
+			Table:       table.Name,
+			Name:        name,
+			Detail:      detail,
+			Statements:  stmts,
+			Destructive: destructive,
+		})
+	}
+
+	for _, col := range table.Cols {
+		liveCol, has := live.Col(col.Name)
+		// unique constraints are compared separately
+		def, err := s.columnDefinition(col)
+		if err != nil {
+			return nil, err
+		}
+		if !has {
+			// existing rows have no value for NOT NULL column without
+			// default
+			required := col.Notnull && !col.Default && !col.AutoIncr && col.Generated == ""
+			// SQLite can't add columns with constraints, or NOT NULL ones
+			// without default.
+			if style == AlterRebuild && (col.Primary || col.Unique || (col.Generated != "" && !col.Virtual) || (col.Notnull && !col.Default)) {
+				rebuild = append(rebuild, "add column "+col.Name)
+				unsafe = unsafe || required
+				continue
+			}
+			add(ChangeAddColumn, col.Name, "", false, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;\n", tname, def))
+			diff[len(diff)-1].Unsafe = required && style == AlterStandard
+			continue
+		}
+
+		dbTyp, defaultVal, err := s.columnType(col)
+		if err != nil {
+			return nil, err
+		}
+		var (
+			cname   = s.EscapeName(col.Name)
+			changes []string
+		)
+		if col.Generated == "" && liveCol.Generated == "" && !s.sameType(dbTyp, liveCol.DBType) {
+			changes = append(changes, ChangeColumnType)
+			if style == AlterStandard {
+				add(ChangeColumnType, col.Name, liveCol.DBType+" -> "+dbTyp, true,
+					fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;\n", tname, cname, dbTyp))
+			}
+		}
+		// existing NULL values fail NOT NULL
+		setNotnull := col.Notnull && !liveCol.Notnull && !col.Primary
+		if col.Notnull != liveCol.Notnull && !col.Primary {
+			changes = append(changes, ChangeColumnNull)
+			if style == AlterStandard {
+				action := "DROP NOT NULL"
+				if col.Notnull {
+					action = "SET NOT NULL"
+				}
+				add(ChangeColumnNull, col.Name, action, false,
+					fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s;\n", tname, cname, action))
+				diff[len(diff)-1].Unsafe = setNotnull
+			}
+		}
+		hasDefault := col.Default && col.Generated == ""
+		if !liveCol.AutoIncr && col.Generated == "" &&
+			(hasDefault != liveCol.Default || (hasDefault && normalizeDefault(defaultVal) != normalizeDefault(liveCol.DefaultVal))) {
+			changes = append(changes, ChangeColumnDefault)
+			if style == AlterStandard {
+				action := "DROP DEFAULT"
+				if hasDefault {
+					action = "SET DEFAULT " + defaultVal
+				}
+				add(ChangeColumnDefault, col.Name, action, false,
+					fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s;\n", tname, cname, action))
+			}
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		switch style {
+		case AlterModify:
+			destructive := changes[0] == ChangeColumnType
+			add(changes[0], col.Name, strings.Join(changes, ", "), destructive,
+				fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s;\n", tname, def))
+			diff[len(diff)-1].Unsafe = setNotnull
+		case AlterRebuild:
+			if changes[0] == ChangeColumnType {
+				dropped = true
+			}
+			unsafe = unsafe || setNotnull
+			rebuild = append(rebuild, strings.Join(changes, ", ")+" "+col.Name)
+		}
+	}
+	for _, liveCol := range live.Cols {
+		if _, has := table.Col(liveCol.Name); has {
+			continue
+		}
+		if style == AlterRebuild && (liveCol.Primary || liveCol.Unique || liveCol.ForeignTable != "" || live.indexed(liveCol.Name)) {
+			dropped = true
+			rebuild = append(rebuild, "drop column "+liveCol.Name)
+			continue
+		}
+		add(ChangeDropColumn, liveCol.Name, "", true,
+			fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;\n", tname, s.EscapeName(liveCol.Name)))
+	}
+
+	var (
+		constraints = s.tableConstraints(table)
+		liveMatched = make([]bool, len(live.Constraints))
+	)
+	for _, c := range constraints {
+		var found bool
+		for i, lc := range live.Constraints {
+			if !liveMatched[i] && sameConstraint(c, lc) {
+				liveMatched[i], found = true, true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		if style == AlterRebuild {
+			rebuild = append(rebuild, "add "+strings.ToLower(c.Type)+" "+strings.Join(c.Cols, ","))
+			continue
+		}
+		add(ChangeAddConstraint, c.Name, c.Type+" ("+strings.Join(c.Cols, ", ")+")", false,
+			fmt.Sprintf("ALTER TABLE %s ADD %s;\n", tname, s.constraintDefinition(c)))
+	}
+	for i, lc := range live.Constraints {
+		if liveMatched[i] {
+			continue
+		}
+		if style == AlterRebuild {
+			rebuild = append(rebuild, "drop "+strings.ToLower(lc.Type)+" "+strings.Join(lc.Cols, ","))
+			continue
+		}
+		var stmt string
+		switch {
+		case style != AlterModify:
+			stmt = fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;\n", tname, s.EscapeName(lc.Name))
+		case lc.Type == ConstraintPrimaryKey:
+			stmt = fmt.Sprintf("ALTER TABLE %s DROP PRIMARY KEY;\n", tname)
+		case lc.Type == ConstraintForeignKey:
+			stmt = fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s;\n", tname, s.EscapeName(lc.Name))
+		default:
+			stmt = fmt.Sprintf("ALTER TABLE %s DROP INDEX %s;\n", tname, s.EscapeName(lc.Name))
+		}
+		add(ChangeDropConstraint, lc.Name, lc.Type+" ("+strings.Join(lc.Cols, ", ")+")", false, stmt)
+	}
+
+	if len(rebuild) > 0 {
+		stmts, err := s.rebuildTableSQL(table, live)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range diff {
+			if c.Kind == ChangeDropColumn {
+				dropped = true
+				rebuild = append(rebuild, "drop column "+c.Name)
+			}
+		}
+		diff = nil
+		add(ChangeRebuildTable, table.Name, strings.Join(rebuild, "; "), dropped, stmts...)
+		diff[0].Unsafe = unsafe
+		return diff, nil
+	}
+
+	indexes := s.tableIndexes(table)
+	for _, idx := range indexes {
+		var found bool
+		for _, li := range live.Indexes {
+			if sameIndex(idx, li) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			add(ChangeAddIndex, idx.Name, strings.Join(idx.Cols, ", "), false, s.createIndexSQL(table.Name, idx))
+		}
+	}
+	for _, li := range live.Indexes {
+		var found bool
+		for _, idx := range indexes {
+			if sameIndex(idx, li) {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		stmt := fmt.Sprintf("DROP INDEX %s;\n", s.EscapeName(li.Name))
+		if style == AlterModify {
+			stmt = fmt.Sprintf("DROP INDEX %s ON %s;\n", s.EscapeName(li.Name), tname)
+		}
+		add(ChangeDropIndex, li.Name, strings.Join(li.Cols, ", "), false, stmt)
+	}
+	diff.sort()
+	return diff, nil
+}
+
+func (t TableSchema) indexed(col string) bool {
+	for _, idx := range t.Indexes {
+		for _, c := range idx.Cols {
+			if c == col {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *SQLUtil) constraintDefinition(c Constraint) string {
+	var def string
+	if c.Name != "" {
+		def = "CONSTRAINT " + s.EscapeName(c.Name) + " "
+	}
+	def += c.Type + " (" + s.escapeNames(c.Cols) + ")"
+	if c.Type == ConstraintForeignKey {
+		def += " REFERENCES " + s.EscapeName(c.ForeignTable) + " (" + s.escapeNames(c.ForeignCols) + ")"
+	}
+	return def
+}
+
+// rebuildTableSQL recreates table from model by copying rows of the columns
+// kept into a new table. Foreign key enforcement should be disabled while
+// running it.
+func (s *SQLUtil) rebuildTableSQL(table Table, live TableSchema) ([]string, error) {
+	tmp := table
+	tmp.Name = table.Name + "__sqldb_new"
+	createSQL, err := s.createTableSQL(tmp, s.tableConstraints(table))
+	if err != nil {
+		return nil, err
+	}
+	var cols []string
+	for _, col := range table.Cols {
+		liveCol, has := live.Col(col.Name)
+		if has && col.Generated == "" && liveCol.Generated == "" {
+			cols = append(cols, col.Name)
+		}
+	}
+	stmts := []string{
+		createSQL,
+		fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s;\n",
+			s.EscapeName(tmp.Name), s.escapeNames(cols), s.escapeNames(cols), s.EscapeName(table.Name)),
+		fmt.Sprintf("DROP TABLE %s;\n", s.EscapeName(table.Name)),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s;\n", s.EscapeName(tmp.Name), s.EscapeName(table.Name)),
+	}
+	return append(stmts, s.CreateIndexSQL(table)...), nil
+}
@@ -0,0 +1,185 @@
+package sqldb
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDiffSchemaSQLite3(t *testing.T) {
+	type ItemV1 struct {
+		Id    int64  `sqldb:"pk table:item"`
+		Name  string `sqldb:"precision:32"`
+		Price int64
+		Old   string
+	}
+	type ItemV2 struct {
+		Id    int64  `sqldb:"pk table:item"`
+		Name  string `sqldb:"precision:32 index"`
+		Price int64  `sqldb:"default:1"`
+		Note  *string
+	}
+	ctx := context.Background()
+	db := openSQLite3(t)
+	parser := NewTableParser(TableParserOptions{Default: true, Notnull: true})
+	su := NewSQLUtil(parser, SQLite3{})
+	if err := su.CreateTables(db, ItemV1{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO item (id, name, price, old) VALUES (1, 'a', 10, 'x')`); err != nil {
+		t.Fatal(err)
+	}
+	v1, _ := parser.StructTable(ItemV1{})
+	if diff, err := su.DiffSchema(ctx, db, v1); err != nil || len(diff) != 0 {
+		t.Fatalf("expect no changes, got %+v, %v", diff, err)
+	}
+
+	v2, _ := parser.StructTable(ItemV2{})
+	diff, err := su.DiffSchema(ctx, db, v2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != 1 || diff[0].Kind != ChangeRebuildTable || !diff[0].Destructive {
+		t.Fatalf("expect destructive rebuild, got %+v", diff)
+	}
+	if len(diff.Safe()) != 0 || len(diff.Destructive()) != 1 || len(diff.Unsafe()) != 0 {
+		t.Fatal("unexpected safe/destructive split")
+	}
+	for _, stmt := range diff.Statements() {
+		if _, err = db.Exec(stmt); err != nil {
+			t.Fatal(stmt, err)
+		}
+	}
+	if diff, err = su.DiffSchema(ctx, db, v2); err != nil || len(diff) != 0 {
+		t.Fatalf("expect no changes after rebuild, got %+v, %v", diff, err)
+	}
+	var (
+		name  string
+		price int64
+	)
+	if err = db.QueryRow(`SELECT name, price FROM item WHERE id = 1`).Scan(&name, &price); err != nil || name != "a" || price != 10 {
+		t.Fatal("rows should be kept by rebuild", name, price, err)
+	}
+
+	type ItemV3 struct {
+		Id    int64  `sqldb:"pk table:item"`
+		Name  string `sqldb:"precision:32 index"`
+		Price int64  `sqldb:"default:1 index:ix_price"`
+		Note  *string
+		Tag   *string
+	}
+	v3, _ := parser.StructTable(ItemV3{})
+	diff, err = su.DiffSchema(ctx, db, v3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != 2 || diff[0].Kind != ChangeAddColumn || diff[1].Kind != ChangeAddIndex || len(diff.Destructive()) != 0 {
+		t.Fatalf("expect add column and index, got %+v", diff)
+	}
+	for _, stmt := range diff.Statements() {
+		if _, err = db.Exec(stmt); err != nil {
+			t.Fatal(stmt, err)
+		}
+	}
+	if diff, err = su.DiffSchema(ctx, db, v3); err != nil || len(diff) != 0 {
+		t.Fatalf("expect no changes, got %+v, %v", diff, err)
+	}
+
+	type ItemV4 struct {
+		Id    int64  `sqldb:"pk table:item"`
+		Name  string `sqldb:"precision:32 index"`
+		Price int64  `sqldb:"default:1 index:ix_price"`
+		Note  *string
+		Tag   *string
+		Code  string `sqldb:"default:-"`
+	}
+	v4, _ := parser.StructTable(ItemV4{})
+	diff, err = su.DiffSchema(ctx, db, v4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != 1 || diff[0].Kind != ChangeRebuildTable || !diff[0].Unsafe || len(diff.Safe()) != 0 || len(diff.Unsafe()) != 1 {
+		t.Fatalf("expect unsafe rebuild adding NOT NULL column without default, got %+v", diff)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	for _, stmt := range diff.Statements() {
+		if _, err = tx.Exec(stmt); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		t.Fatal("expect rebuild failing on existing rows")
+	}
+}
+
+func TestDiffTableStatements(t *testing.T) {
+	type User struct {
+		Id    int64  `sqldb:"pk"`
+		Email string `sqldb:"precision:128 unique"`
+		Age   int32  `sqldb:"default:18"`
+		Nick  *string
+	}
+	parser := NewTableParser(TableParserOptions{Default: true, Notnull: true})
+	table, _ := parser.StructTable(User{})
+	live := TableSchema{
+		Table: Table{Name: "user", Cols: []Column{
+			{Name: "id", DBType: "bigint", Notnull: true, Primary: true},
+			{Name: "email", DBType: "character varying(64)", Notnull: true},
+			{Name: "age", DBType: "integer"},
+			{Name: "legacy", DBType: "text"},
+		}},
+		Indexes: []Index{{Name: "ix_user_legacy", Cols: []string{"legacy"}}},
+		Constraints: []Constraint{
+			{Name: "user_pkey", Type: ConstraintPrimaryKey, Cols: []string{"id"}},
+		},
+	}
+	diff, err := NewSQLUtil(parser, Postgres{}).DiffTable(table, live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{
+		`DROP INDEX "ix_user_legacy";`,
+		`ALTER TABLE "user" ADD COLUMN "nick" VARCHAR(64) ;`,
+		`ALTER TABLE "user" ALTER COLUMN "email" TYPE VARCHAR(128);`,
+		`ALTER TABLE "user" ALTER COLUMN "age" SET NOT NULL;`,
+		`ALTER TABLE "user" ALTER COLUMN "id" SET DEFAULT 0;`,
+		`ALTER TABLE "user" ALTER COLUMN "email" SET DEFAULT '';`,
+		`ALTER TABLE "user" ALTER COLUMN "age" SET DEFAULT 18;`,
+		`ALTER TABLE "user" DROP COLUMN "legacy";`,
+		`ALTER TABLE "user" ADD CONSTRAINT "uq_user_email" UNIQUE ("email");`,
+	}
+	got := diff.Statements()
+	if len(got) != len(expect) {
+		t.Fatalf("expect %d statements, got %q", len(expect), got)
+	}
+	for i := range expect {
+		if strings.TrimSpace(got[i]) != expect[i] {
+			t.Errorf("%d: expect %q, got %q", i, expect[i], got[i])
+		}
+	}
+	if len(diff.Destructive()) != 2 {
+		t.Fatalf("expect type change and drop column destructive: %+v", diff.Destructive())
+	}
+	if unsafe := diff.Unsafe(); len(unsafe) != 1 || unsafe[0].Name != "age" {
+		t.Fatalf("expect SET NOT NULL unsafe: %+v", unsafe)
+	}
+
+	diff, err = NewSQLUtil(parser, MySQL{}).DiffTable(table, live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var modify []string
+	for _, stmt := range diff.Statements() {
+		if strings.Contains(stmt, "MODIFY") || strings.Contains(stmt, "DROP INDEX") {
+			modify = append(modify, strings.TrimSpace(stmt))
+		}
+	}
+	if len(modify) != 4 || modify[0] != `DROP INDEX "ix_user_legacy" ON "user";` ||
+		modify[1] != `ALTER TABLE "user" MODIFY COLUMN "email" VARCHAR(128)  NOT NULL DEFAULT '';` {
+		t.Fatalf("unexpected mysql statements: %q", modify)
+	}
+}
@@ -0,0 +1,214 @@
+package sqldb
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// condition is a SQL condition with ? placeholders and its arguments, or an
+// expression rendered on build.
+type condition struct {
+	sql  string
+	args []interface{}
+	expr Expr
+}
+
+// SelectBuilder builds SELECT statements of a model, conditions use ?
+// placeholders which are rendered in the style of dialect. Soft-deleted rows
+// are skipped unless Unscoped is called.
+type SelectBuilder struct {
+	b   *SQLBuilder
+	err error
+
+	table    Table
+	distinct bool
+	columns  []string
+	where    []condition
+	groupBy  []string
+	having   []condition
+	orderBy  []string
+	limit    int
+	offset   int
+	unscoped bool
+}
+
+// Select starts a SELECT statement of model, all columns are selected by
+// default.
+func (b *SQLBuilder) Select(model interface{}) *SelectBuilder {
+	table, err := b.SQLUtil.parser.StructTable(model)
+	return &SelectBuilder{
+		b:      b,
+		err:    err,
+		table:  table,
+		limit:  -1,
+		offset: -1,
+	}
+}
+
+func (s *SelectBuilder) Columns(cols ...string) *SelectBuilder {
+	s.columns = append(s.columns, cols...)
+	return s
+}
+
+// Group selects columns of the named column group.
+func (s *SelectBuilder) Group(group string) *SelectBuilder {
+	cols := s.b.SQLUtil.groupColumns(s.table, group)
+	if len(cols) == 0 && s.err == nil {
+		s.err = fmt.Errorf("%s: column group %s is empty", s.table.Name, group)
+	}
+	return s.Columns(cols...)
+}
+
+func (s *SelectBuilder) Distinct() *SelectBuilder {
+	s.distinct = true
+	return s
+}
+
+// Where adds a condition, conditions are joined by AND.
+func (s *SelectBuilder) Where(cond string, args ...interface{}) *SelectBuilder {
+	s.where = append(s.where, condition{sql: cond, args: args})
+	return s
+}
+
+// WhereExpr is like Where but adds an expression, its columns are checked
+// against the model table.
+func (s *SelectBuilder) WhereExpr(e Expr) *SelectBuilder {
+	if e == nil && s.err == nil {
+		s.err = fmt.Errorf("%s: nil expression", s.table.Name)
+	}
+	s.where = append(s.where, condition{expr: e})
+	return s
+}
+
+func (s *SelectBuilder) GroupBy(cols ...string) *SelectBuilder {
+	s.groupBy = append(s.groupBy, cols...)
+	return s
+}
+
+// Having adds a condition of groups, conditions are joined by AND.
+func (s *SelectBuilder) Having(cond string, args ...interface{}) *SelectBuilder {
+	s.having = append(s.having, condition{sql: cond, args: args})
+	return s
+}
+
+// OrderBy adds ordering expressions such as "name", "created DESC".
+func (s *SelectBuilder) OrderBy(exprs ...string) *SelectBuilder {
+	s.orderBy = append(s.orderBy, exprs...)
+	return s
+}
+
+func (s *SelectBuilder) Limit(n int) *SelectBuilder {
+	s.limit = n
+	return s
+}
+
+func (s *SelectBuilder) Offset(n int) *SelectBuilder {
+	s.offset = n
+	return s
+}
+
+// Unscoped also selects soft-deleted rows.
+func (s *SelectBuilder) Unscoped() *SelectBuilder {
+	s.unscoped = true
+	return s
+}
+
+// joinConditions joins conditions by AND, each one is parenthesized if there
+// are more than one.
+func joinConditions(table Table, conds []condition) (string, []interface{}, error) {
+	var (
+		sqls = make([]string, len(conds))
+		args []interface{}
+	)
+	for i, c := range conds {
+		sql, condArgs := c.sql, c.args
+		if c.expr != nil {
+			var err error
+			sql, condArgs, err = renderExpr(table, c.expr)
+			if err != nil {
+				return "", nil, err
+			}
+		}
+		sqls[i] = sql
+		if len(conds) > 1 {
+			sqls[i] = "(" + sql + ")"
+		}
+		args = append(args, condArgs...)
+	}
+	return strings.Join(sqls, " AND "), args, nil
+}
+
+// Build returns the statement and its arguments.
+func (s *SelectBuilder) Build() (string, []interface{}, error) {
+	if s.err != nil {
+		return "", nil, s.err
+	}
+	if s.limit < -1 || s.offset < -1 {
+		return "", nil, fmt.Errorf("%s: negative limit or offset", s.table.Name)
+	}
+	var (
+		buf  bytes.Buffer
+		args []interface{}
+	)
+	buf.WriteString("SELECT ")
+	if s.distinct {
+		buf.WriteString("DISTINCT ")
+	}
+	columns := s.columns
+	if len(columns) == 0 {
+		columns = s.b.SQLUtil.tableColumns(s.table, nil)
+	}
+	buf.WriteString(ColumnNames(columns).List())
+	buf.WriteString(" FROM ")
+	buf.WriteString(s.table.Name)
+
+	where, whereArgs, err := joinConditions(s.table, s.where)
+	if err != nil {
+		return "", nil, err
+	}
+	buf.WriteString(s.b.scopedWhereClause(s.table, where, !s.unscoped))
+	args = append(args, whereArgs...)
+	if len(s.groupBy) > 0 {
+		buf.WriteString(" GROUP BY ")
+		buf.WriteString(strings.Join(s.groupBy, ", "))
+	}
+	if len(s.having) > 0 {
+		having, havingArgs, err := joinConditions(s.table, s.having)
+		if err != nil {
+			return "", nil, err
+		}
+		buf.WriteString(" HAVING ")
+		buf.WriteString(having)
+		args = append(args, havingArgs...)
+	}
+	if len(s.orderBy) > 0 {
+		buf.WriteString(" ORDER BY ")
+		buf.WriteString(strings.Join(s.orderBy, ", "))
+	}
+	s.writeLimit(&buf)
+	return s.b.SQLUtil.Bind(buf.String(), args...)
+}
+
+func (s *SelectBuilder) writeLimit(buf *bytes.Buffer) {
+	features := dialectFeatures(s.b.SQLUtil.dialect)
+	if features.OffsetFetch {
+		if s.offset >= 0 || s.limit >= 0 {
+			buf.WriteString(" OFFSET " + strconv.Itoa(max(s.offset, 0)) + " ROWS")
+		}
+		if s.limit >= 0 {
+			buf.WriteString(" FETCH NEXT " + strconv.Itoa(s.limit) + " ROWS ONLY")
+		}
+		return
+	}
+	switch {
+	case s.limit >= 0:
+		buf.WriteString(" LIMIT " + strconv.Itoa(s.limit))
+	case s.offset >= 0 && features.NoLimit != "":
+		buf.WriteString(" LIMIT " + features.NoLimit)
+	}
+	if s.offset >= 0 {
+		buf.WriteString(" OFFSET " + strconv.Itoa(s.offset))
+	}
+}
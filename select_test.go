@@ -0,0 +1,106 @@
+package sqldb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// offsetFetchDialect is Postgres limiting rows by OFFSET ... FETCH.
+type offsetFetchDialect struct{ Postgres }
+
+func (offsetFetchDialect) Features() DialectFeatures {
+	features := Postgres{}.Features()
+	features.OffsetFetch = true
+	return features
+}
+
+func TestSelectBuilder(t *testing.T) {
+	type Post struct {
+		Id        int64 `sqldb:"pk"`
+		Author    string
+		Title     string     `sqldb:"group:summary"`
+		Views     int        `sqldb:"group:summary"`
+		DeletedAt *time.Time `sqldb:"softdelete"`
+	}
+	var (
+		p        = NewTableParser()
+		postgres = NewSQLBuilder(NewSQLUtil(p, Postgres{}))
+		mysql    = NewSQLBuilder(NewSQLUtil(p, MySQL{}))
+		sqlite3  = NewSQLBuilder(NewSQLUtil(p, SQLite3{}))
+		fetch    = NewSQLBuilder(NewSQLUtil(p, offsetFetchDialect{}))
+	)
+	type testCase struct {
+		Builder *SelectBuilder
+		SQL     string
+		Args    []interface{}
+	}
+	cases := []testCase{
+		{
+			Builder: postgres.Select(Post{}),
+			SQL:     "SELECT id, author, title, views, deleted_at FROM post WHERE deleted_at IS NULL",
+		},
+		{
+			Builder: postgres.Select(Post{}).Columns("id", "title").Where("author = ?", "bob").Where("views > ? OR title = '?'", 10).OrderBy("views DESC", "id").Limit(10).Offset(20),
+			SQL:     "SELECT id, title FROM post WHERE ((author = $1) AND (views > $2 OR title = '?')) AND deleted_at IS NULL ORDER BY views DESC, id LIMIT 10 OFFSET 20",
+			Args:    []interface{}{"bob", 10},
+		},
+		{
+			Builder: mysql.Select(Post{}).Group("summary").Where("author = ?", "bob").Limit(5),
+			SQL:     "SELECT title, views FROM post WHERE (author = ?) AND deleted_at IS NULL LIMIT 5",
+			Args:    []interface{}{"bob"},
+		},
+		{
+			Builder: mysql.Select(Post{}).Columns("id").Unscoped().Offset(3),
+			SQL:     "SELECT id FROM post LIMIT 18446744073709551615 OFFSET 3",
+		},
+		{
+			Builder: sqlite3.Select(Post{}).Columns("id").Unscoped().Offset(3),
+			SQL:     "SELECT id FROM post LIMIT -1 OFFSET 3",
+		},
+		{
+			Builder: postgres.Select(Post{}).Columns("id").Unscoped().Offset(3),
+			SQL:     "SELECT id FROM post OFFSET 3",
+		},
+		{
+			Builder: postgres.Select(Post{}).Distinct().Columns("author", "COUNT(*)").Where("views > ?", 1).GroupBy("author").Having("COUNT(*) > ?", 2).OrderBy("author"),
+			SQL:     "SELECT DISTINCT author, COUNT(*) FROM post WHERE (views > $1) AND deleted_at IS NULL GROUP BY author HAVING COUNT(*) > $2 ORDER BY author",
+			Args:    []interface{}{1, 2},
+		},
+		{
+			Builder: fetch.Select(Post{}).Columns("id").Unscoped().OrderBy("id").Limit(10).Offset(20),
+			SQL:     "SELECT id FROM post ORDER BY id OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY",
+		},
+		{
+			Builder: fetch.Select(Post{}).Columns("id").Unscoped().OrderBy("id").Limit(10),
+			SQL:     "SELECT id FROM post ORDER BY id OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY",
+		},
+		{
+			Builder: fetch.Select(Post{}).Columns("id").Unscoped().OrderBy("id").Offset(20),
+			SQL:     "SELECT id FROM post ORDER BY id OFFSET 20 ROWS",
+		},
+		{
+			Builder: fetch.Select(Post{}).Columns("id").Unscoped(),
+			SQL:     "SELECT id FROM post",
+		},
+	}
+	for i, c := range cases {
+		sql, args, err := c.Builder.Build()
+		if err != nil {
+			t.Fatalf("%d: %s", i, err)
+		}
+		if sql != c.SQL {
+			t.Errorf("%d: expect %q, but got %q", i, c.SQL, sql)
+		}
+		if !reflect.DeepEqual(args, c.Args) {
+			t.Errorf("%d: expect args %v, but got %v", i, c.Args, args)
+		}
+	}
+
+	if _, _, err := postgres.Select(Post{}).Group("missing").Build(); err == nil {
+		t.Error("expect error for empty column group")
+	}
+	if _, _, err := postgres.Select(Post{}).Limit(-2).Build(); err == nil {
+		t.Error("expect error for negative limit")
+	}
+}
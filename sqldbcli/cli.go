@@ -0,0 +1,127 @@
+// Package sqldbcli exports DDL of registered models, it's used by a small
+// main package of the application:
+//
+//	func main() {
+//		parser := sqldb.NewTableParser()
+//		if err := parser.Register(models.User{}, models.Post{}); err != nil {
+//			log.Fatal(err)
+//		}
+//		sqldbcli.Main(parser)
+//	}
+//
+// Run it as `go run ./cmd/schema -dialect postgres -dir schema`.
+package sqldbcli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	sqldb "github.com/cosiner/go-sqldb"
+)
+
+// Main runs with command line arguments and exits on error.
+func Main(parser *sqldb.TableParser) {
+	err := Run(parser, os.Args[1:], os.Stdout)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// Run prints DDL of tables registered in parser in dependency order, or
+// writes one file per table if -dir is given. args don't include the program
+// name.
+func Run(parser *sqldb.TableParser, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("sqldb", flag.ContinueOnError)
+	var (
+		dialectName = flags.String("dialect", "postgres", "database dialect: postgres, mysql or sqlite3")
+		dir         = flags.String("dir", "", "write one file per table into directory instead of stdout")
+		tables      = flags.String("tables", "", "comma separated tables, all registered tables if empty")
+		triggers    = flags.Bool("triggers", false, "include triggers maintaining updated columns")
+	)
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	dialect, err := sqldb.DialectByName(*dialectName)
+	if err != nil {
+		return err
+	}
+	selected, err := selectTables(parser.Tables(), *tables)
+	if err != nil {
+		return err
+	}
+	su := sqldb.NewSQLUtil(parser, dialect)
+	su.EnableUpdatedTriggers(*triggers)
+	ddls, err := su.TablesDDL(selected...)
+	if err != nil {
+		return err
+	}
+	if *dir == "" {
+		return printDDL(stdout, ddls)
+	}
+	return writeDDLFiles(*dir, ddls)
+}
+
+func selectTables(tables []sqldb.Table, names string) ([]sqldb.Table, error) {
+	if names == "" {
+		return tables, nil
+	}
+	var selected []sqldb.Table
+	for _, name := range strings.Split(names, ",") {
+		var found bool
+		for _, t := range tables {
+			if t.Name == name {
+				selected = append(selected, t)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("table %s is not registered", name)
+		}
+	}
+	return selected, nil
+}
+
+func printDDL(w io.Writer, ddls []sqldb.TableDDL) error {
+	var stmts, deferred []string
+	for _, ddl := range ddls {
+		stmts = append(stmts, ddl.Statements...)
+		deferred = append(deferred, ddl.Deferred...)
+	}
+	_, err := io.WriteString(w, strings.Join(append(stmts, deferred...), "\n"))
+	return err
+}
+
+// writeDDLFiles writes DDL of each table into NNN_TABLE.sql, deferred foreign
+// keys are written into the last file NNN_deferred.sql. Files are numbered in
+// dependency order.
+func writeDDLFiles(dir string, ddls []sqldb.TableDDL) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	var deferred []string
+	for i, ddl := range ddls {
+		name := fmt.Sprintf("%03d_%s.sql", i+1, ddl.Table)
+		err = os.WriteFile(filepath.Join(dir, name), []byte(strings.Join(ddl.Statements, "\n")), 0644)
+		if err != nil {
+			return err
+		}
+		deferred = append(deferred, ddl.Deferred...)
+	}
+	if len(deferred) == 0 {
+		return nil
+	}
+	name := fmt.Sprintf("%03d_deferred.sql", len(ddls)+1)
+	return os.WriteFile(filepath.Join(dir, name), []byte(strings.Join(deferred, "\n")), 0644)
+}
@@ -0,0 +1,84 @@
+package sqldbcli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sqldb "github.com/cosiner/go-sqldb"
+)
+
+type employee struct {
+	Id   int64 `sqldb:"pk"`
+	Dept int64 `sqldb:"fk:department.id"`
+}
+
+type department struct {
+	Id      int64 `sqldb:"pk"`
+	Manager int64 `sqldb:"fk:employee.id index"`
+}
+
+type project struct {
+	Id   int64 `sqldb:"pk"`
+	Dept int64 `sqldb:"fk:department.id"`
+}
+
+func TestRun(t *testing.T) {
+	parser := sqldb.NewTableParser()
+	if err := parser.Register(project{}, employee{}, department{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	if err := Run(parser, []string{"-dialect", "postgres"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	ddl := out.String()
+	positions := []int{
+		strings.Index(ddl, `CREATE TABLE IF NOT EXISTS "employee"`),
+		strings.Index(ddl, `CREATE TABLE IF NOT EXISTS "department"`),
+		strings.Index(ddl, `CREATE INDEX IF NOT EXISTS "ix_department_manager"`),
+		strings.Index(ddl, `CREATE TABLE IF NOT EXISTS "project"`),
+		strings.Index(ddl, `ALTER TABLE "employee" ADD CONSTRAINT "fk_employee_dept"`),
+	}
+	for i, pos := range positions {
+		if pos < 0 || (i > 0 && pos < positions[i-1]) {
+			t.Fatalf("unexpected statement order %v:\n%s", positions, ddl)
+		}
+	}
+
+	dir := t.TempDir()
+	if err := Run(parser, []string{"-dialect", "mysql", "-dir", dir}, &out); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if strings.Join(names, ",") != "001_employee.sql,002_department.sql,003_project.sql,004_deferred.sql" {
+		t.Fatal("unexpected files", names)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "004_deferred.sql"))
+	if err != nil || !strings.HasPrefix(string(content), `ALTER TABLE "employee" ADD`) {
+		t.Fatal("unexpected deferred file", string(content), err)
+	}
+
+	out.Reset()
+	if err = Run(parser, []string{"-dialect", "sqlite3", "-tables", "project"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(out.String(), "CREATE TABLE") != 1 {
+		t.Fatal("only selected table should be exported", out.String())
+	}
+	if err = Run(parser, []string{"-tables", "missing"}, &out); err == nil {
+		t.Fatal("unregistered table should fail")
+	}
+	if err = Run(parser, []string{"-dialect", "oracle"}, &out); err == nil {
+		t.Fatal("unknown dialect should fail")
+	}
+}
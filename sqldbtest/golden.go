@@ -0,0 +1,90 @@
+// Package sqldbtest helps testing schemas of go-sqldb models.
+package sqldbtest
+
+import (
+	"errors"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sqldb "github.com/cosiner/go-sqldb"
+)
+
+var update = flag.Bool("sqldb.update", false, "update golden DDL files")
+
+// Dialects are the dialect names rendered by AssertGoldenDDL by default.
+var Dialects = []string{"postgres", "mysql", "sqlite3"}
+
+// RenderDDL renders the DDL creating registered tables of parser in
+// dependency order.
+func RenderDDL(parser *sqldb.TableParser, dialect sqldb.DBDialect) (string, error) {
+	stmts, err := sqldb.NewSQLUtil(parser, dialect).CreateTablesSQL(parser.Tables()...)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(stmts, "\n"), nil
+}
+
+// AssertGoldenDDL renders registered tables of parser for each dialect, or
+// Dialects if none, and compares them with dir/DIALECT.sql. Golden files are
+// written instead if the test runs with -sqldb.update.
+func AssertGoldenDDL(t testing.TB, parser *sqldb.TableParser, dir string, dialects ...string) {
+	t.Helper()
+	if len(dialects) == 0 {
+		dialects = Dialects
+	}
+	for _, name := range dialects {
+		dialect, err := sqldb.DialectByName(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ddl, err := RenderDDL(parser, dialect)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		path := filepath.Join(dir, name+".sql")
+		if *update {
+			err = os.MkdirAll(dir, 0755)
+			if err == nil {
+				err = os.WriteFile(path, []byte(ddl), 0644)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		golden, err := os.ReadFile(path)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("%s: golden file missing, run with -sqldb.update to create it", path)
+				continue
+			}
+			t.Fatal(err)
+		}
+		if line, want, got, ok := firstDiff(string(golden), ddl); !ok {
+			t.Errorf("%s: line %d differs, run with -sqldb.update if it's expected\nwant: %s\n got: %s", path, line, want, got)
+		}
+	}
+}
+
+// firstDiff returns the first different line of a and b.
+func firstDiff(a, b string) (line int, la, lb string, same bool) {
+	if a == b {
+		return 0, "", "", true
+	}
+	as, bs := strings.Split(a, "\n"), strings.Split(b, "\n")
+	for i := 0; ; i++ {
+		if i >= len(as) || i >= len(bs) || as[i] != bs[i] {
+			if i < len(as) {
+				la = as[i]
+			}
+			if i < len(bs) {
+				lb = bs[i]
+			}
+			return i + 1, la, lb, false
+		}
+	}
+}
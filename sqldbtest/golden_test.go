@@ -0,0 +1,58 @@
+package sqldbtest
+
+import (
+	"strings"
+	"testing"
+
+	sqldb "github.com/cosiner/go-sqldb"
+)
+
+type author struct {
+	Id    int64  `sqldb:"pk"`
+	Email string `sqldb:"unique:uq_author_email"`
+	Name  string `sqldb:"unique:uq_author_name index"`
+	Bio   *string
+}
+
+type book struct {
+	Id     int64  `sqldb:"pk"`
+	Author int64  `sqldb:"fk:author.id"`
+	Title  string `sqldb:"precision:128 unique:uq_book_title"`
+	Isbn   string `sqldb:"unique:uq_book_title"`
+}
+
+func TestAssertGoldenDDL(t *testing.T) {
+	parser := sqldb.NewTableParser(sqldb.TableParserOptions{Default: true, Notnull: true})
+	if err := parser.Register(book{}, author{}); err != nil {
+		t.Fatal(err)
+	}
+	AssertGoldenDDL(t, parser, "testdata")
+
+	// rendering is stable across runs
+	first, err := RenderDDL(parser, sqldb.Postgres{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		ddl, err := RenderDDL(parser, sqldb.Postgres{})
+		if err != nil || ddl != first {
+			t.Fatal("rendering should be deterministic", err)
+		}
+	}
+}
+
+func TestFirstDiff(t *testing.T) {
+	line, a, b, same := firstDiff("a\nb\nc", "a\nx\nc")
+	if same || line != 2 || a != "b" || b != "x" {
+		t.Fatal("unexpected diff", line, a, b)
+	}
+	if _, _, _, same = firstDiff("a\n", "a\n"); !same {
+		t.Fatal("expect same")
+	}
+	if line, a, b, _ = firstDiff("a", "a\nb"); line != 2 || a != "" || b != "b" {
+		t.Fatal("unexpected diff", line, a, b)
+	}
+	if !strings.Contains(strings.Join(Dialects, ","), "sqlite3") {
+		t.Fatal("sqlite3 should be rendered by default")
+	}
+}
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS "author" (
+    "id" BIGINT  NOT NULL DEFAULT 0,
+    "email" VARCHAR(64)  NOT NULL DEFAULT '',
+    "name" VARCHAR(64)  NOT NULL DEFAULT '',
+    "bio" VARCHAR(64) ,
+    CONSTRAINT "pk_author" PRIMARY KEY ("id"),
+    CONSTRAINT "uq_author_email" UNIQUE ("email"),
+    CONSTRAINT "uq_author_name" UNIQUE ("name")
+);
+
+CREATE INDEX "ix_author_name" ON "author" ("name");
+
+CREATE TABLE IF NOT EXISTS "book" (
+    "id" BIGINT  NOT NULL DEFAULT 0,
+    "author" BIGINT  NOT NULL DEFAULT 0,
+    "title" VARCHAR(128)  NOT NULL DEFAULT '',
+    "isbn" VARCHAR(64)  NOT NULL DEFAULT '',
+    CONSTRAINT "pk_book" PRIMARY KEY ("id"),
+    CONSTRAINT "uq_book_title" UNIQUE ("title", "isbn"),
+    CONSTRAINT "fk_book_author" FOREIGN KEY ("author") REFERENCES "author" ("id")
+);
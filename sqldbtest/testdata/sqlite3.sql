@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS "author" (
+    "id" INTEGER  NOT NULL DEFAULT 0,
+    "email" TEXT  NOT NULL DEFAULT '',
+    "name" TEXT  NOT NULL DEFAULT '',
+    "bio" TEXT ,
+    CONSTRAINT "pk_author" PRIMARY KEY ("id"),
+    CONSTRAINT "uq_author_email" UNIQUE ("email"),
+    CONSTRAINT "uq_author_name" UNIQUE ("name")
+);
+
+CREATE INDEX IF NOT EXISTS "ix_author_name" ON "author" ("name");
+
+CREATE TABLE IF NOT EXISTS "book" (
+    "id" INTEGER  NOT NULL DEFAULT 0,
+    "author" INTEGER  NOT NULL DEFAULT 0,
+    "title" TEXT  NOT NULL DEFAULT '',
+    "isbn" TEXT  NOT NULL DEFAULT '',
+    CONSTRAINT "pk_book" PRIMARY KEY ("id"),
+    CONSTRAINT "uq_book_title" UNIQUE ("title", "isbn"),
+    CONSTRAINT "fk_book_author" FOREIGN KEY ("author") REFERENCES "author" ("id")
+);
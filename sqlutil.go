@@ -2,11 +2,16 @@ package sqldb
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 type ColumnNames []string
@@ -177,12 +182,16 @@ func (c ColumnNames) InplaceRemove(cols ...string) ColumnNames {
 type SQLUtil struct {
 	dialect DBDialect
 	parser  *TableParser
+
+	updatedTriggers bool
+	naming          NamingStrategy
 }
 
 func NewSQLUtil(parser *TableParser, dialect DBDialect) *SQLUtil {
 	return &SQLUtil{
 		parser:  parser,
 		dialect: dialect,
+		naming:  DefaultNaming{},
 	}
 }
 func (s *SQLUtil) TableParser() *TableParser {
@@ -192,6 +201,12 @@ func (s *SQLUtil) DBDialect() DBDialect {
 	return s.dialect
 }
 
+// EnableUpdatedTriggers makes CreateTables also create the triggers of
+// UpdatedTriggerSQL.
+func (s *SQLUtil) EnableUpdatedTriggers(enable bool) {
+	s.updatedTriggers = enable
+}
+
 func (s *SQLUtil) TableName(v interface{}) string {
 	t, err := s.parser.StructTable(v)
 	if err != nil {
@@ -205,6 +220,15 @@ func (s *SQLUtil) TableColumns(v interface{}, excepts ...string) ColumnNames {
 	if err != nil {
 		return nil
 	}
+	return s.tableColumns(t, excepts)
+}
+
+func (s *SQLUtil) table(v interface{}) Table {
+	t, _ := s.parser.StructTable(v)
+	return t
+}
+
+func (s *SQLUtil) tableColumns(t Table, excepts []string) ColumnNames {
 	cols := make(ColumnNames, 0, len(t.Cols))
 	for _, c := range t.Cols {
 		if !ColumnNames(excepts).Contains(c.Name) {
@@ -214,99 +238,624 @@ func (s *SQLUtil) TableColumns(v interface{}, excepts ...string) ColumnNames {
 	return cols
 }
 
+// TableColumnsGroup returns columns of the named group.
+func (s *SQLUtil) TableColumnsGroup(v interface{}, group string) ColumnNames {
+	return s.groupColumns(s.table(v), group)
+}
+
+func (s *SQLUtil) groupColumns(t Table, group string) ColumnNames {
+	var cols ColumnNames
+	for _, c := range t.Cols {
+		if c.InGroup(group) {
+			cols = append(cols, c.Name)
+		}
+	}
+	return cols
+}
+
+// InsertColumns is like TableColumns but only returns columns written by
+// INSERT.
+func (s *SQLUtil) InsertColumns(v interface{}, excepts ...string) ColumnNames {
+	return s.insertColumns(s.table(v), excepts)
+}
+
+// UpdateColumns is like TableColumns but only returns columns written by
+// UPDATE.
+func (s *SQLUtil) UpdateColumns(v interface{}, excepts ...string) ColumnNames {
+	return s.updateColumns(s.table(v), excepts)
+}
+
+func (s *SQLUtil) insertColumns(t Table, excepts []string) ColumnNames {
+	cols := make(ColumnNames, 0, len(t.Cols))
+	for _, c := range t.Cols {
+		if c.Insertable() && !ColumnNames(excepts).Contains(c.Name) {
+			cols = append(cols, c.Name)
+		}
+	}
+	return cols
+}
+
+func (s *SQLUtil) updateColumns(t Table, excepts []string) ColumnNames {
+	cols := make(ColumnNames, 0, len(t.Cols))
+	for _, c := range t.Cols {
+		if c.Updatable() && !ColumnNames(excepts).Contains(c.Name) {
+			cols = append(cols, c.Name)
+		}
+	}
+	return cols
+}
+
+// TableNameOf is the error-returning, typed form of TableName.
+func TableNameOf[T any](s *SQLUtil) (string, error) {
+	t, err := TableOf[T](s.parser)
+	if err != nil {
+		return "", err
+	}
+	return t.Name, nil
+}
+
+// ColumnsOf is the error-returning, typed form of TableColumns, it fails if
+// no column is left.
+func ColumnsOf[T any](s *SQLUtil, excepts ...string) (ColumnNames, error) {
+	t, err := TableOf[T](s.parser)
+	if err != nil {
+		return nil, err
+	}
+	cols := s.tableColumns(t, excepts)
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("%s: no columns", t.Name)
+	}
+	return cols, nil
+}
+
+// ColumnsGroupOf is the error-returning, typed form of TableColumnsGroup, it
+// fails if the group has no column.
+func ColumnsGroupOf[T any](s *SQLUtil, group string) (ColumnNames, error) {
+	t, err := TableOf[T](s.parser)
+	if err != nil {
+		return nil, err
+	}
+	cols := s.groupColumns(t, group)
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("%s: no columns in group %s", t.Name, group)
+	}
+	return cols, nil
+}
+
+// CreateTables creates tables of models, see CreateTablesContext.
 func (s *SQLUtil) CreateTables(db *sql.DB, models ...interface{}) error {
-	for _, mod := range models {
-		table, err := s.parser.StructTable(mod)
+	return s.CreateTablesContext(context.Background(), db, CreateTablesOptions{}, models...)
+}
+
+type CreateTablesOptions struct {
+	// DryRun receives the statements instead of executing them if not nil,
+	// executor may be nil then.
+	DryRun io.Writer
+	// LockName is the cross-process lock held while creating tables if the
+	// dialect is a Locker, it's shared with Migrator by default.
+	LockName string
+	// LockTimeout is the time waiting for the lock, one minute by default.
+	LockTimeout time.Duration
+	// NoLock disables locking, such as the caller holding the lock already.
+	NoLock bool
+}
+
+// tableStatement is a DDL statement and the table it belongs to.
+type tableStatement struct {
+	table    string
+	stmt     string
+	deferred bool
+}
+
+// CreateTablesContext creates tables of models in dependency order, foreign
+// keys in a cycle are added by ALTER TABLE after all tables are created unless
+// the dialect allows referencing tables created later. Statements are run in
+// a transaction if the dialect supports transactional DDL and ex is able to
+// begin one, a *sql.Tx is used as is. Colliding constraint and index names
+// fail before anything is created, see CheckNames.
+//
+// If the dialect is a Locker, the lock of CreateTablesOptions is held on a
+// connection taken from ex while creating tables, so concurrent processes
+// don't race with each other or Migrator. A *sql.Tx can't take the lock, the
+// caller should hold it on the connection of the transaction.
+func (s *SQLUtil) CreateTablesContext(ctx context.Context, ex Executor, opts CreateTablesOptions, models ...interface{}) error {
+	tables, err := s.modelTables(models)
+	if err != nil {
+		return err
+	}
+	tables = sortTables(tables)
+	if opts.DryRun != nil {
+		stmts, err := s.createTablesSQL(ctx, ex, tables)
 		if err != nil {
 			return err
 		}
-		s, err := s.CreateTableSQL(table)
+		for _, stmt := range stmts {
+			_, err = io.WriteString(opts.DryRun, stmt.stmt)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if _, ok := s.dialect.(Locker); ok && !opts.NoLock {
+		conn, release, err := lockConn(ctx, ex)
 		if err != nil {
-			return fmt.Errorf("%s: %s", table.Name, err.Error())
+			return err
 		}
-		_, err = db.Exec(s)
+		defer release()
+		if conn != nil {
+			name, timeout := opts.LockName, opts.LockTimeout
+			if name == "" {
+				name = defaultLockName
+			}
+			if timeout == 0 {
+				timeout = defaultLockTimeout
+			}
+			return s.withConnLock(ctx, conn, name, timeout, func() error {
+				return s.createTables(ctx, conn, tables)
+			})
+		}
+	}
+	return s.createTables(ctx, ex, tables)
+}
+
+func (s *SQLUtil) createTables(ctx context.Context, ex Executor, tables []Table) (err error) {
+	stmts, err := s.createTablesSQL(ctx, ex, tables)
+	if err != nil {
+		return err
+	}
+	if beginner, ok := ex.(TxBeginner); ok && dialectFeatures(s.dialect).TransactionalDDL {
+		var tx *sql.Tx
+		tx, err = beginner.BeginTx(ctx, nil)
 		if err != nil {
-			return fmt.Errorf("%s: %s", table.Name, err.Error())
+			return err
+		}
+		defer TxDone(tx, &err)
+		ex = tx
+	}
+	for _, stmt := range stmts {
+		_, err = ex.ExecContext(ctx, stmt.stmt)
+		if err != nil {
+			return fmt.Errorf("%s: %s", stmt.table, err.Error())
 		}
 	}
 	return nil
 }
 
-func (s *SQLUtil) EscapeName(name string) string {
-	return `"` + name + `"`
+// CreateTablesSQL returns the statements creating tables in dependency order,
+// such as the registered ones from TableParser.Tables.
+func (s *SQLUtil) CreateTablesSQL(tables ...Table) ([]string, error) {
+	tableStmts, err := s.createTablesSQL(context.Background(), nil, sortTables(tables))
+	if err != nil {
+		return nil, err
+	}
+	stmts := make([]string, len(tableStmts))
+	for i, stmt := range tableStmts {
+		stmts[i] = stmt.stmt
+	}
+	return stmts, nil
 }
 
-func (s *SQLUtil) CreateTableSQL(table Table) (string, error) {
-	var buf bytes.Buffer
-	fmt.Fprintf(&buf, "CREATE TABLE IF NOT EXISTS %s (\n", s.EscapeName(table.Name))
+// TableDDL is the statements creating a table.
+type TableDDL struct {
+	Table      string
+	Statements []string
+	// Deferred adds foreign keys in a cycle, it must run after all tables
+	// are created.
+	Deferred []string
+}
+
+// TablesDDL returns the statements creating tables grouped by table, in
+// dependency order.
+func (s *SQLUtil) TablesDDL(tables ...Table) ([]TableDDL, error) {
+	tables = sortTables(tables)
+	tableStmts, err := s.createTablesSQL(context.Background(), nil, tables)
+	if err != nil {
+		return nil, err
+	}
+	ddls := make([]TableDDL, len(tables))
+	for i, table := range tables {
+		ddls[i].Table = table.Name
+		for _, stmt := range tableStmts {
+			if stmt.table != table.Name {
+				continue
+			}
+			if stmt.deferred {
+				ddls[i].Deferred = append(ddls[i].Deferred, stmt.stmt)
+			} else {
+				ddls[i].Statements = append(ddls[i].Statements, stmt.stmt)
+			}
+		}
+	}
+	return ddls, nil
+}
+
+// createTablesSQL returns statements creating sorted tables, existing
+// deferred foreign keys are skipped if q isn't nil and the dialect supports
+// schema inspection.
+func (s *SQLUtil) createTablesSQL(ctx context.Context, q Queryer, tables []Table) ([]tableStatement, error) {
+	if err := s.CheckNames(tables...); err != nil {
+		return nil, err
+	}
 	var (
-		uniques   map[string][]string
-		primaries []string
-		foreigns  []int
-		lastQuite string
+		stmts    []tableStatement
+		deferred = s.deferredForeignKeys(tables)
 	)
-	for i, col := range table.Cols {
-		dbTyp, defaultVal, err := s.dialect.Type(col.Type, col.Precision, col.DefaultVal)
+	add := func(table string, deferred bool, sqls ...string) {
+		for _, stmt := range sqls {
+			stmts = append(stmts, tableStatement{table: table, stmt: stmt, deferred: deferred})
+		}
+	}
+	for _, table := range tables {
+		createSQL, err := s.createTableSQL(table, s.undeferredConstraints(table, deferred[table.Name]))
 		if err != nil {
-			return "", err
+			return nil, fmt.Errorf("%s: %s", table.Name, err.Error())
 		}
-		if col.Primary {
-			primaries = append(primaries, s.EscapeName(col.Name))
+		add(table.Name, false, createSQL)
+		add(table.Name, false, s.CreateIndexSQL(table)...)
+		if s.updatedTriggers {
+			add(table.Name, false, s.UpdatedTriggerSQL(table)...)
 		}
-		if col.ForeignTable != "" {
-			foreigns = append(foreigns, i)
+	}
+	for _, table := range tables {
+		if len(deferred[table.Name]) == 0 {
+			continue
 		}
-		if col.DBType != "" {
-			dbTyp = col.DBType
+		alters, err := s.deferredForeignKeySQL(ctx, q, table, deferred[table.Name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", table.Name, err.Error())
 		}
-		var constraints string
-		if col.Unique {
-			if col.UniqueName == "" {
-				constraints += " UNIQUE"
-			} else {
-				if uniques == nil {
-					uniques = make(map[string][]string)
+		add(table.Name, true, alters...)
+	}
+	return stmts, nil
+}
+
+// DropTables drops tables of models in reverse dependency order, cascade also
+// drops objects depending on them if the dialect supports it.
+func (s *SQLUtil) DropTables(db *sql.DB, cascade bool, models ...interface{}) error {
+	tables, err := s.modelTables(models)
+	if err != nil {
+		return err
+	}
+	tables = sortTables(tables)
+	for i := len(tables) - 1; i >= 0; i-- {
+		_, err = db.Exec(s.DropTableSQL(tables[i], cascade))
+		if err != nil {
+			return fmt.Errorf("%s: %s", tables[i].Name, err.Error())
+		}
+	}
+	return nil
+}
+
+func (s *SQLUtil) DropTableSQL(table Table, cascade bool) string {
+	dropSQL := "DROP TABLE IF EXISTS " + s.EscapeName(table.Name)
+	if cascade && dialectFeatures(s.dialect).DropCascade {
+		dropSQL += " CASCADE"
+	}
+	return dropSQL + ";\n"
+}
+
+func (s *SQLUtil) modelTables(models []interface{}) ([]Table, error) {
+	tables := make([]Table, 0, len(models))
+	for _, mod := range models {
+		table, err := s.parser.StructTable(mod)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// deferredForeignKeys returns columns of sorted tables referencing tables
+// created after them, keyed by table name. They only exist in foreign key
+// cycles.
+func (s *SQLUtil) deferredForeignKeys(sorted []Table) map[string]map[string]bool {
+	if dialectFeatures(s.dialect).ForwardForeignKeys {
+		return nil
+	}
+	created := make(map[string]bool, len(sorted))
+	deferred := make(map[string]map[string]bool)
+	for _, t := range sorted {
+		created[t.Name] = true
+		for _, col := range t.Cols {
+			if col.ForeignTable == "" || created[col.ForeignTable] {
+				continue
+			}
+			var later bool
+			for _, other := range sorted {
+				if other.Name == col.ForeignTable {
+					later = true
+					break
 				}
-				uniques[col.UniqueName] = append(uniques[col.UniqueName], s.EscapeName(col.Name))
 			}
+			if !later {
+				continue
+			}
+			if deferred[t.Name] == nil {
+				deferred[t.Name] = make(map[string]bool)
+			}
+			deferred[t.Name][col.Name] = true
 		}
-		if col.AutoIncr {
-			constraints += " AUTO INCREAMENT"
+	}
+	return deferred
+}
+
+// undeferredConstraints returns constraints of table except foreign keys of
+// deferred columns.
+func (s *SQLUtil) undeferredConstraints(table Table, deferred map[string]bool) []Constraint {
+	var constraints []Constraint
+	for _, c := range s.tableConstraints(table) {
+		if c.Type != ConstraintForeignKey || !deferred[c.Cols[0]] {
+			constraints = append(constraints, c)
 		}
-		if !col.Notnull {
-			constraints += " NOT NULL"
+	}
+	return constraints
+}
+
+// deferredForeignKeySQL returns ALTER TABLE statements adding deferred foreign
+// keys of table, those already exist are skipped if q isn't nil and the
+// dialect supports schema inspection.
+func (s *SQLUtil) deferredForeignKeySQL(ctx context.Context, q Queryer, table Table, cols map[string]bool) ([]string, error) {
+	var live []Constraint
+	if inspector, ok := s.dialect.(SchemaInspector); ok && q != nil {
+		schema, err := inspector.InspectTable(ctx, q, table.Name)
+		if err != nil && !errors.Is(err, ErrNoTable) {
+			return nil, err
+		}
+		live = schema.Constraint(ConstraintForeignKey)
+	}
+	var stmts []string
+	for _, c := range s.tableConstraints(table) {
+		if c.Type != ConstraintForeignKey || !cols[c.Cols[0]] {
+			continue
 		}
-		if col.Default {
-			constraints += " DEFAULT " + defaultVal
+		var exists bool
+		for _, l := range live {
+			if sameConstraint(c, l) {
+				exists = true
+				break
+			}
 		}
-		lastQuite = ""
-		if i != len(table.Cols)-1 || len(primaries) != 0 || len(uniques) != 0 || len(foreigns) != 0 {
-			lastQuite = ","
+		if !exists {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ADD %s;\n", s.EscapeName(table.Name), s.constraintDefinition(c)))
 		}
-		fmt.Fprintf(&buf, "    %s %s %s%s\n", s.EscapeName(col.Name), dbTyp, constraints, lastQuite)
 	}
-	if len(primaries) > 0 {
-		lastQuite = ""
-		if len(uniques) != 0 || len(foreigns) != 0 {
-			lastQuite = ","
+	return stmts, nil
+}
+
+// tableIndexes returns indexes of table, unnamed indexes are named by the
+// naming strategy.
+func (s *SQLUtil) tableIndexes(table Table) []Index {
+	naming := s.naming
+	if naming == nil {
+		naming = DefaultNaming{}
+	}
+	var indexes []Index
+	for _, col := range table.Cols {
+		if !col.Index {
+			continue
+		}
+		for _, name := range strings.Split(col.IndexName, ",") {
+			if name == "" {
+				name = naming.Index(table.Name, []string{col.Name})
+			}
+			name = s.identifier(name)
+			var found bool
+			for i := range indexes {
+				if indexes[i].Name == name {
+					indexes[i].Cols = append(indexes[i].Cols, col.Name)
+					found = true
+					break
+				}
+			}
+			if !found {
+				indexes = append(indexes, Index{Name: name, Cols: []string{col.Name}})
+			}
 		}
-		fmt.Fprintf(&buf, "    PRIMARY KEY (%s)%s\n", strings.Join(primaries, ","), lastQuite)
 	}
-	for name, keys := range uniques {
-		lastQuite = ""
-		if len(foreigns) != 0 || len(uniques) != 1 {
-			lastQuite = ","
+	return indexes
+}
+
+// tableConstraints returns the primary key, unique and foreign key
+// constraints of table, unnamed ones are named by the naming strategy or
+// have empty name if there is none.
+func (s *SQLUtil) tableConstraints(table Table) []Constraint {
+	var (
+		pk          = Constraint{Type: ConstraintPrimaryKey}
+		uniques     []Constraint
+		foreigns    []Constraint
+		constraints []Constraint
+	)
+	for _, col := range table.Cols {
+		if col.Primary {
+			pk.Cols = append(pk.Cols, col.Name)
+		}
+		if col.Unique {
+			for _, name := range strings.Split(col.UniqueName, ",") {
+				var found bool
+				if name != "" {
+					for i := range uniques {
+						if uniques[i].Name == s.identifier(name) {
+							uniques[i].Cols = append(uniques[i].Cols, col.Name)
+							found = true
+							break
+						}
+					}
+				}
+				if !found {
+					uniques = append(uniques, Constraint{Name: name, Type: ConstraintUnique, Cols: []string{col.Name}})
+					if name == "" && s.naming != nil {
+						uniques[len(uniques)-1].Name = s.naming.Unique(table.Name, []string{col.Name})
+					}
+					uniques[len(uniques)-1].Name = s.identifier(uniques[len(uniques)-1].Name)
+				}
+			}
+		}
+		if col.ForeignTable != "" {
+			fk := Constraint{
+				Type:         ConstraintForeignKey,
+				Cols:         []string{col.Name},
+				ForeignTable: col.ForeignTable,
+				ForeignCols:  []string{col.ForeignCol},
+			}
+			if s.naming != nil {
+				fk.Name = s.identifier(s.naming.ForeignKey(table.Name, fk.Cols))
+			}
+			foreigns = append(foreigns, fk)
 		}
-		fmt.Fprintf(&buf, "    CONSTRAINT %s UNIQUE (%s)%s\n", name, strings.Join(keys, ","), lastQuite)
-		delete(uniques, name)
 	}
-	for i, index := range foreigns {
-		col := table.Cols[index]
-		lastQuite = ""
-		if i != len(foreigns)-1 {
-			lastQuite = ","
+	if len(pk.Cols) > 0 {
+		if s.naming != nil {
+			pk.Name = s.identifier(s.naming.PrimaryKey(table.Name))
 		}
-		fmt.Fprintf(&buf, "    FOREIGN KEY(%s) REFERENCES %s(%s)%s\n", s.EscapeName(col.Name), col.ForeignTable, col.ForeignCol, lastQuite)
+		constraints = append(constraints, pk)
+	}
+	constraints = append(constraints, uniques...)
+	return append(constraints, foreigns...)
+}
+
+func (s *SQLUtil) escapeNames(names []string) string {
+	escaped := make([]string, len(names))
+	for i, name := range names {
+		escaped[i] = s.EscapeName(name)
+	}
+	return strings.Join(escaped, ", ")
+}
+
+func (s *SQLUtil) createIndexSQL(table string, index Index) string {
+	create := "CREATE INDEX "
+	if index.Unique {
+		create = "CREATE UNIQUE INDEX "
+	}
+	if dialectFeatures(s.dialect).CreateIndexIfNotExists {
+		create += "IF NOT EXISTS "
+	}
+	return fmt.Sprintf("%s%s ON %s (%s);\n", create, s.EscapeName(index.Name), s.EscapeName(table), s.escapeNames(index.Cols))
+}
+
+// CreateIndexSQL returns the statements creating indexes of table.
+func (s *SQLUtil) CreateIndexSQL(table Table) []string {
+	var stmts []string
+	for _, index := range s.tableIndexes(table) {
+		stmts = append(stmts, s.createIndexSQL(table.Name, index))
+	}
+	return stmts
+}
+
+// UpdatedTriggerSQL returns the statements creating a trigger which keeps the
+// updated column of table correct, it's empty if there is no such column or
+// the dialect isn't an UpdatedTriggerer. The trigger is named by the naming
+// strategy.
+func (s *SQLUtil) UpdatedTriggerSQL(table Table) []string {
+	col, has := table.UpdatedCol()
+	if !has {
+		return nil
+	}
+	triggerer, ok := s.dialect.(UpdatedTriggerer)
+	if !ok {
+		return nil
+	}
+	naming := s.naming
+	if naming == nil {
+		naming = DefaultNaming{}
+	}
+	return triggerer.UpdatedTrigger(s.identifier(naming.Trigger(table.Name, col.Name)), table.Name, col.Name)
+}
+
+func (s *SQLUtil) EscapeName(name string) string {
+	return `"` + name + `"`
+}
+
+// placeholder returns the n-th positional parameter, starting from 1.
+func (s *SQLUtil) placeholder(n int) string {
+	if dialectFeatures(s.dialect).Placeholder == PlaceholderDollar {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+// columnType returns database type and rendered default value of column.
+func (s *SQLUtil) columnType(col Column) (dbTyp, defaultVal string, err error) {
+	dbTyp, defaultVal, err = s.dialect.Type(col.Type, col.Precision, col.DefaultVal)
+	if err != nil {
+		if col.DBType == "" {
+			return "", "", err
+		}
+		defaultVal = col.DefaultVal
+	}
+	if col.DBType != "" {
+		dbTyp = col.DBType
+	}
+	return dbTyp, defaultVal, nil
+}
+
+// columnDefinition returns the column definition of CREATE TABLE and ALTER
+// TABLE, keys and unique constraints are not included.
+func (s *SQLUtil) columnDefinition(col Column) (string, error) {
+	err := s.checkIdentifier(col.Name)
+	if err != nil {
+		return "", err
+	}
+	dbTyp, defaultVal, err := s.columnType(col)
+	if err != nil {
+		return "", err
+	}
+	var constraints string
+	if col.AutoIncr {
+		constraints += " AUTO INCREAMENT"
+	}
+	if col.Generated != "" {
+		if col.Virtual && !dialectFeatures(s.dialect).VirtualGenerated {
+			return "", fmt.Errorf("%s: virtual generated column is unsupported", col.Name)
+		}
+		constraints += " GENERATED ALWAYS AS (" + col.Generated + ")"
+		if col.Virtual {
+			constraints += " VIRTUAL"
+		} else {
+			constraints += " STORED"
+		}
+	}
+	if col.Notnull {
+		constraints += " NOT NULL"
+	}
+	if col.Default && col.Generated == "" {
+		constraints += " DEFAULT " + defaultVal
+	}
+	return fmt.Sprintf("%s %s %s", s.EscapeName(col.Name), dbTyp, constraints), nil
+}
+
+func (s *SQLUtil) CreateTableSQL(table Table) (string, error) {
+	return s.createTableSQL(table, s.tableConstraints(table))
+}
+
+// createTableSQL renders table with constraints.
+func (s *SQLUtil) createTableSQL(table Table, constraints []Constraint) (string, error) {
+	err := s.checkIdentifier(table.Name)
+	if err != nil {
+		return "", err
+	}
+	defs := make([]string, 0, len(table.Cols)+len(constraints))
+	for _, col := range table.Cols {
+		def, err := s.columnDefinition(col)
+		if err != nil {
+			return "", err
+		}
+		defs = append(defs, def)
+	}
+	for _, c := range constraints {
+		defs = append(defs, s.constraintDefinition(c))
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "CREATE TABLE IF NOT EXISTS %s (\n", s.EscapeName(table.Name))
+	for i, def := range defs {
+		lastQuite := ","
+		if i == len(defs)-1 {
+			lastQuite = ""
+		}
+		fmt.Fprintf(&buf, "    %s%s\n", def, lastQuite)
 	}
 	fmt.Fprintf(&buf, ");\n")
 	return buf.String(), nil
@@ -314,6 +863,11 @@ func (s *SQLUtil) CreateTableSQL(table Table) (string, error) {
 
 type SQLBuilder struct {
 	SQLUtil *SQLUtil
+	// Clock provides values of created and updated columns, callers should
+	// fill them by Touch before executing the statements, or bind arguments
+	// by BindNamed which fills them. CURRENT_TIMESTAMP of database is used if
+	// it's nil.
+	Clock func() time.Time
 
 	mu            sync.RWMutex
 	indexSQLCache map[uintptr][]string
@@ -389,61 +943,120 @@ func (b *SQLBuilder) whereClause(s string) string {
 	return " WHERE " + s
 }
 
+// scopedWhereClause adds conds and the "not deleted" predicate of soft-delete
+// tables to s.
+func (b *SQLBuilder) scopedWhereClause(table Table, s string, scoped bool, conds ...string) string {
+	if col, has := table.SoftDeleteCol(); has && scoped {
+		if col.Type == "bool" {
+			conds = append(conds, col.Name+" IS NOT TRUE")
+		} else {
+			conds = append(conds, col.Name+" IS NULL")
+		}
+	}
+	if len(conds) == 0 {
+		return b.whereClause(s)
+	}
+	if s != "" {
+		s = "(" + s + ") AND "
+	}
+	return " WHERE " + s + strings.Join(conds, " AND ")
+}
+
 func (b *SQLBuilder) WhereColumns(cols ...string) string {
 	return ColumnNames(cols).NamedCond("AND", "=")
 }
 
-func (b *SQLBuilder) Query(model interface{}, columns []string, where string) string {
-	table := b.SQLUtil.TableName(model)
+func (b *SQLBuilder) query(model interface{}, columns []string, where string, scoped bool) string {
+	table := b.SQLUtil.table(model)
 	if len(columns) == 0 {
-		columns = b.SQLUtil.TableColumns(model)
+		columns = b.SQLUtil.tableColumns(table, nil)
 	}
 	return fmt.Sprintf(
 		"SELECT %s FROM %s%s",
 		ColumnNames(columns).List(),
-		table,
-		b.whereClause(where),
+		table.Name,
+		b.scopedWhereClause(table, where, scoped),
 	)
 }
 
-func (b *SQLBuilder) IsExist(model interface{}, resultName string, where string) string {
-	table := b.SQLUtil.TableName(model)
+func (b *SQLBuilder) Query(model interface{}, columns []string, where string) string {
+	return b.query(model, columns, where, true)
+}
+
+// QueryUnscoped is like Query but also selects soft-deleted rows.
+func (b *SQLBuilder) QueryUnscoped(model interface{}, columns []string, where string) string {
+	return b.query(model, columns, where, false)
+}
+
+func (b *SQLBuilder) isExist(model interface{}, resultName string, where string, scoped bool) string {
+	table := b.SQLUtil.table(model)
 	return fmt.Sprintf(
-		"SELECT EXISTS(SELECT 1 FROM %s%s) AS %s",
-		table,
-		b.whereClause(where),
+		"EXISTS(SELECT 1 FROM %s%s) AS %s",
+		table.Name,
+		b.scopedWhereClause(table, where, scoped),
 		resultName,
 	)
 }
 
+func (b *SQLBuilder) IsExist(model interface{}, resultName string, where string) string {
+	return "SELECT " + b.isExist(model, resultName, where, true)
+}
+
+func (b *SQLBuilder) IsExistUnscoped(model interface{}, resultName string, where string) string {
+	return "SELECT " + b.isExist(model, resultName, where, false)
+}
+
 type CheckIsExistGroup struct {
 	Model      interface{}
 	ResultName string
 	Where      string
 }
 
-func (b *SQLBuilder) MultiIsExist(groups ...CheckIsExistGroup) string {
+func (b *SQLBuilder) multiIsExist(groups []CheckIsExistGroup, scoped bool) string {
 	var buffer bytes.Buffer
 	buffer.WriteString("SELECT ")
 	var isFirst = true
 	for _, g := range groups {
-		table := b.SQLUtil.TableName(g.Model)
 		if isFirst {
 			isFirst = false
 		} else {
 			buffer.WriteString(", ")
 		}
-		_, _ = fmt.Fprintf(&buffer,
-			"EXISTS(SELECT 1 FROM %s%s) AS %s",
-			table,
-			b.whereClause(g.Where),
-			g.ResultName,
-		)
+		buffer.WriteString(b.isExist(g.Model, g.ResultName, g.Where, scoped))
 	}
 	return buffer.String()
 }
 
+func (b *SQLBuilder) MultiIsExist(groups ...CheckIsExistGroup) string {
+	return b.multiIsExist(groups, true)
+}
+
+func (b *SQLBuilder) MultiIsExistUnscoped(groups ...CheckIsExistGroup) string {
+	return b.multiIsExist(groups, false)
+}
+
+// Delete marks rows as deleted for soft-delete tables, otherwise it's same
+// as HardDelete.
 func (b *SQLBuilder) Delete(model interface{}, where string) string {
+	table := b.SQLUtil.table(model)
+	col, has := table.SoftDeleteCol()
+	if !has {
+		return b.HardDelete(model, where)
+	}
+	var marker string
+	if col.Type == "bool" {
+		marker = col.Name + " = TRUE"
+	} else {
+		marker = col.Name + " = CURRENT_TIMESTAMP"
+	}
+	return fmt.Sprintf("UPDATE %s SET %s%s",
+		table.Name,
+		marker,
+		b.scopedWhereClause(table, where, true),
+	)
+}
+
+func (b *SQLBuilder) HardDelete(model interface{}, where string) string {
 	table := b.SQLUtil.TableName(model)
 
 	return fmt.Sprintf("DELETE FROM %s%s",
@@ -452,32 +1065,115 @@ func (b *SQLBuilder) Delete(model interface{}, where string) string {
 	)
 }
 
+// timestampValue returns the value of created or updated column.
+func (b *SQLBuilder) timestampValue(col Column) string {
+	if b.Clock == nil {
+		return "CURRENT_TIMESTAMP"
+	}
+	return ":" + col.Name
+}
+
+func (b *SQLBuilder) insertValues(table Table, columns ColumnNames) string {
+	values := make(ColumnNames, 0, len(columns))
+	for _, c := range columns {
+		col, _ := table.Col(c)
+		if col.Created || col.Updated {
+			values = append(values, b.timestampValue(col))
+		} else {
+			values = append(values, ":"+c)
+		}
+	}
+	return values.List()
+}
+
 func (b *SQLBuilder) Insert(model interface{}) string {
-	table := b.SQLUtil.TableName(model)
-	columns := ColumnNames(b.SQLUtil.TableColumns(model))
-	return fmt.Sprintf("INSERT INTO %s(%s) VALUES(%s)", table, columns.List(), columns.NamedList())
+	table := b.SQLUtil.table(model)
+	columns := b.SQLUtil.insertColumns(table, nil)
+	return fmt.Sprintf("INSERT INTO %s(%s) VALUES(%s)", table.Name, columns.List(), b.insertValues(table, columns))
 }
 
 func (b *SQLBuilder) InsertUnique(model interface{}, checkExist string) string {
-	table := b.SQLUtil.TableName(model)
-	columns := ColumnNames(b.SQLUtil.TableColumns(model))
+	table := b.SQLUtil.table(model)
+	columns := b.SQLUtil.insertColumns(table, nil)
 	return fmt.Sprintf("INSERT INTO %s(%s) SELECT %s WHERE NOT EXISTS(SELECT 1 FROM %s WHERE %s)",
-		table,
+		table.Name,
 		columns.List(),
-		columns.NamedList(),
-		table,
+		b.insertValues(table, columns),
+		table.Name,
 		checkExist,
 	)
 }
 
-func (b *SQLBuilder) Update(model interface{}, columns []string, where string) string {
-	table := b.SQLUtil.TableName(model)
+// Touch fills created and updated fields of model, it must be a pointer of
+// structure. It does nothing if Clock is nil.
+func (b *SQLBuilder) Touch(model interface{}, created bool) error {
+	if b.Clock == nil {
+		return nil
+	}
+	table, err := b.SQLUtil.parser.StructTable(model)
+	if err != nil {
+		return err
+	}
+	refv := reflect.ValueOf(model)
+	if refv.Kind() != reflect.Ptr {
+		return fmt.Errorf("%s: touch needs pointer of structure", table.Name)
+	}
+	refv = refv.Elem()
+	now := b.Clock()
+	for _, col := range table.Cols {
+		if !col.Updated && !(created && col.Created) {
+			continue
+		}
+		field := refv.FieldByIndex(col.Field.Index)
+		if field.Kind() == reflect.Ptr {
+			// fields must not share the pointer
+			t := now
+			field.Set(reflect.ValueOf(&t))
+		} else {
+			field.Set(reflect.ValueOf(now))
+		}
+	}
+	return nil
+}
+
+func (b *SQLBuilder) update(model interface{}, columns []string, where string, scoped bool) string {
+	table := b.SQLUtil.table(model)
 	if len(columns) == 0 {
-		columns = b.SQLUtil.TableColumns(model)
+		columns = b.SQLUtil.updateColumns(table, nil)
+	}
+	var (
+		sets    = ColumnNames(columns).Copy()
+		updates []string
+		conds   []string
+	)
+	for _, col := range table.Cols {
+		if col.immutable() {
+			sets = sets.InplaceRemove(col.Name)
+		}
+	}
+	if col, has := table.VersionCol(); has {
+		sets = sets.InplaceRemove(col.Name)
+		updates = append(updates, col.Name+" = "+col.Name+" + 1")
+		conds = append(conds, col.Name+" = :"+col.Name)
+	}
+	if col, has := table.UpdatedCol(); has {
+		sets = sets.InplaceRemove(col.Name)
+		updates = append(updates, col.Name+" = "+b.timestampValue(col))
+	}
+	if len(sets) > 0 {
+		updates = append([]string{sets.NamedUpdate()}, updates...)
 	}
 	return fmt.Sprintf("UPDATE %s SET %s%s",
-		table,
-		ColumnNames(columns).NamedUpdate(),
-		b.whereClause(where),
+		table.Name,
+		strings.Join(updates, ", "),
+		b.scopedWhereClause(table, where, scoped, conds...),
 	)
 }
+
+func (b *SQLBuilder) Update(model interface{}, columns []string, where string) string {
+	return b.update(model, columns, where, true)
+}
+
+func (b *SQLBuilder) UpdateUnscoped(model interface{}, columns []string, where string) string {
+	return b.update(model, columns, where, false)
+}
@@ -1,10 +1,15 @@
 package sqldb
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 func newSQLBuilder() *SQLBuilder {
@@ -120,3 +125,513 @@ func TestSQLBuilderCache(t *testing.T) {
 		}
 	}
 }
+
+func TestTypedAccessors(t *testing.T) {
+	sb := newSQLBuilder()
+	type Model struct {
+		Id   string
+		Name string
+	}
+	name, err := TableNameOf[*Model](sb.SQLUtil)
+	if err != nil || name != "model" {
+		t.Fatal("unexpected table name", name, err)
+	}
+	cols, err := ColumnsOf[Model](sb.SQLUtil, "name")
+	if err != nil || cols.List() != "id" {
+		t.Fatal("unexpected columns", cols, err)
+	}
+	if _, err = ColumnsOf[Model](sb.SQLUtil, "id", "name"); err == nil {
+		t.Fatal("expect error for empty columns")
+	}
+	if _, err = TableNameOf[int](sb.SQLUtil); err == nil {
+		t.Fatal("expect error for non-structure type")
+	}
+}
+
+func TestSQLBuilderSoftDelete(t *testing.T) {
+	sb := newSQLBuilder()
+	type Post struct {
+		Id        string `sqldb:"pk"`
+		Title     string
+		DeletedAt *time.Time `sqldb:"softdelete"`
+	}
+	type Flag struct {
+		Id      string `sqldb:"pk"`
+		Removed bool   `sqldb:"softdelete"`
+	}
+	var (
+		p Post
+		f Flag
+	)
+	type testCase struct {
+		Desc   string
+		SQL    string
+		Expect string
+	}
+	cases := []testCase{
+		{Desc: "query skips deleted rows", SQL: sb.Query(p, []string{"id"}, ""), Expect: "SELECT id FROM post WHERE deleted_at IS NULL"},
+		{Desc: "query wraps where", SQL: sb.Query(p, []string{"id"}, "id = :id OR title = :title"), Expect: "SELECT id FROM post WHERE (id = :id OR title = :title) AND deleted_at IS NULL"},
+		{Desc: "unscoped query", SQL: sb.QueryUnscoped(p, []string{"id"}, sb.WhereColumns("id")), Expect: "SELECT id FROM post WHERE id = :id"},
+		{Desc: "exist skips removed flags", SQL: sb.IsExist(f, "exist", sb.WhereColumns("id")), Expect: "SELECT EXISTS(SELECT 1 FROM flag WHERE (id = :id) AND removed IS NOT TRUE) AS exist"},
+		{Desc: "unscoped exist", SQL: sb.IsExistUnscoped(f, "exist", ""), Expect: "SELECT EXISTS(SELECT 1 FROM flag) AS exist"},
+		{Desc: "multi exist", SQL: sb.MultiIsExist(CheckIsExistGroup{p, "p", ""}), Expect: "SELECT EXISTS(SELECT 1 FROM post WHERE deleted_at IS NULL) AS p"},
+		{Desc: "unscoped multi exist", SQL: sb.MultiIsExistUnscoped(CheckIsExistGroup{p, "p", ""}), Expect: "SELECT EXISTS(SELECT 1 FROM post) AS p"},
+		{Desc: "update skips deleted rows", SQL: sb.Update(p, nil, sb.WhereColumns("id")), Expect: "UPDATE post SET id = :id, title = :title WHERE (id = :id) AND deleted_at IS NULL"},
+		{Desc: "unscoped update", SQL: sb.UpdateUnscoped(p, []string{"deleted_at"}, ""), Expect: "UPDATE post SET deleted_at = :deleted_at"},
+		{Desc: "delete sets time marker", SQL: sb.Delete(p, sb.WhereColumns("id")), Expect: "UPDATE post SET deleted_at = CURRENT_TIMESTAMP WHERE (id = :id) AND deleted_at IS NULL"},
+		{Desc: "delete sets bool marker", SQL: sb.Delete(f, ""), Expect: "UPDATE flag SET removed = TRUE WHERE removed IS NOT TRUE"},
+		{Desc: "hard delete", SQL: sb.HardDelete(p, sb.WhereColumns("id")), Expect: "DELETE FROM post WHERE id = :id"},
+	}
+	for _, c := range cases {
+		if c.SQL != c.Expect {
+			t.Errorf("%s: expect %q, but got %q", c.Desc, c.Expect, c.SQL)
+		}
+	}
+
+	type Invalid struct {
+		DeletedAt time.Time `sqldb:"softdelete"`
+	}
+	if _, err := NewTableParser(TableParserOptions{Notnull: true}).StructTable(Invalid{}); err == nil {
+		t.Fatal("expect error for not null softdelete time column")
+	}
+}
+
+type affectedResult int64
+
+func (r affectedResult) LastInsertId() (int64, error) { return 0, nil }
+func (r affectedResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+func TestSQLBuilderVersion(t *testing.T) {
+	sb := newSQLBuilder()
+	type Doc struct {
+		Id        string `sqldb:"pk"`
+		Body      string
+		Version   int64      `sqldb:"version"`
+		DeletedAt *time.Time `sqldb:"softdelete"`
+	}
+	var d Doc
+	type testCase struct {
+		Desc   string
+		SQL    string
+		Expect string
+	}
+	cases := []testCase{
+		{Desc: "update increases and checks version", SQL: sb.Update(d, nil, sb.WhereColumns("id")), Expect: "UPDATE doc SET id = :id, body = :body, version = version + 1 WHERE (id = :id) AND version = :version AND deleted_at IS NULL"},
+		{Desc: "explicit version column is not duplicated", SQL: sb.Update(d, []string{"body", "version"}, sb.WhereColumns("id")), Expect: "UPDATE doc SET body = :body, version = version + 1 WHERE (id = :id) AND version = :version AND deleted_at IS NULL"},
+		{Desc: "unscoped update checks version", SQL: sb.UpdateUnscoped(d, []string{"body"}, ""), Expect: "UPDATE doc SET body = :body, version = version + 1 WHERE version = :version"},
+	}
+	for _, c := range cases {
+		if c.SQL != c.Expect {
+			t.Errorf("%s: expect %q, but got %q", c.Desc, c.Expect, c.SQL)
+		}
+	}
+
+	if err := ErrOnStaleResult(affectedResult(0), nil); err != ErrStaleObject {
+		t.Fatal("expect stale object error")
+	}
+	if err := ErrOnStaleResult(affectedResult(1), nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLBuilderTimestamps(t *testing.T) {
+	sb := newSQLBuilder()
+	type Event struct {
+		Id        string `sqldb:"pk"`
+		Name      string
+		CreatedAt time.Time `sqldb:"created"`
+		UpdatedAt time.Time `sqldb:"updated"`
+	}
+	var e Event
+	type testCase struct {
+		Desc   string
+		SQL    string
+		Expect string
+	}
+	cases := []testCase{
+		{Desc: "insert fills both timestamps", SQL: sb.Insert(e), Expect: "INSERT INTO event(id, name, created_at, updated_at) VALUES(:id, :name, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)"},
+		{Desc: "update fills updated", SQL: sb.Update(e, nil, sb.WhereColumns("id")), Expect: "UPDATE event SET id = :id, name = :name, updated_at = CURRENT_TIMESTAMP WHERE id = :id"},
+		{Desc: "update of listed columns fills updated", SQL: sb.Update(e, []string{"name"}, ""), Expect: "UPDATE event SET name = :name, updated_at = CURRENT_TIMESTAMP"},
+		{Desc: "updated listed explicitly", SQL: sb.Update(e, []string{"updated_at"}, ""), Expect: "UPDATE event SET updated_at = CURRENT_TIMESTAMP"},
+	}
+	for _, c := range cases {
+		if c.SQL != c.Expect {
+			t.Errorf("%s: expect %q, but got %q", c.Desc, c.Expect, c.SQL)
+		}
+	}
+
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	sb.Clock = func() time.Time { return now }
+	if got, expect := sb.Update(e, []string{"name"}, ""), "UPDATE event SET name = :name, updated_at = :updated_at"; got != expect {
+		t.Errorf("expect %q, but got %q", expect, got)
+	}
+	if err := sb.Touch(&e, false); err != nil {
+		t.Fatal(err)
+	}
+	if !e.CreatedAt.IsZero() || !e.UpdatedAt.Equal(now) {
+		t.Fatal("unexpected touch result for update", e)
+	}
+	if err := sb.Touch(&e, true); err != nil {
+		t.Fatal(err)
+	}
+	if !e.CreatedAt.Equal(now) {
+		t.Fatal("unexpected touch result for insert", e)
+	}
+
+	type Note struct {
+		Id        string     `sqldb:"pk"`
+		CreatedAt *time.Time `sqldb:"created"`
+		UpdatedAt *time.Time `sqldb:"updated"`
+	}
+	var n Note
+	if err := sb.Touch(&n, true); err != nil {
+		t.Fatal(err)
+	}
+	if n.CreatedAt == n.UpdatedAt || !n.CreatedAt.Equal(now) || !n.UpdatedAt.Equal(now) {
+		t.Fatal("touched pointer fields should not alias", n)
+	}
+
+	// forgotten Touch fails on binding, SQLBuilder.BindNamed fills from clock
+	insert := sb.Insert(Event{})
+	if _, _, err := sb.SQLUtil.BindNamed(insert, Event{Id: "1"}); err == nil {
+		t.Fatal("expect error for zero timestamps")
+	}
+	query, args, err := sb.BindNamed(insert, Event{Id: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "INSERT INTO event(id, name, created_at, updated_at) VALUES($1, $2, $3, $4)" || args[2] != now || args[3] != now {
+		t.Fatal("unexpected bound insert", query, args)
+	}
+
+	table, _ := sb.SQLUtil.TableParser().StructTable(e)
+	if stmts := sb.SQLUtil.UpdatedTriggerSQL(table); len(stmts) != 3 || !strings.Contains(stmts[0], `NEW."updated_at" = CURRENT_TIMESTAMP`) {
+		t.Fatalf("unexpected trigger: %v", stmts)
+	}
+}
+
+func TestGeneratedColumns(t *testing.T) {
+	sb := newSQLBuilder()
+	type Item struct {
+		Id    string `sqldb:"pk"`
+		Price int64
+		Qty   int64
+		Total int64  `sqldb:"generated:'price * qty'"`
+		Label string `sqldb:"generated:'id || ''-'' || qty' virtual"`
+	}
+	var m Item
+	type testCase struct {
+		Desc   string
+		SQL    string
+		Expect string
+	}
+	cases := []testCase{
+		{Desc: "insert skips generated", SQL: sb.Insert(m), Expect: "INSERT INTO item(id, price, qty) VALUES(:id, :price, :qty)"},
+		{Desc: "update skips generated", SQL: sb.Update(m, nil, ""), Expect: "UPDATE item SET id = :id, price = :price, qty = :qty"},
+		{Desc: "listed generated column is not updated", SQL: sb.Update(m, []string{"qty", "total"}, ""), Expect: "UPDATE item SET qty = :qty"},
+		{Desc: "query reads generated", SQL: sb.Query(m, nil, ""), Expect: "SELECT id, price, qty, total, label FROM item"},
+		{Desc: "insert columns skip generated", SQL: sb.SQLUtil.InsertColumns(m, "id").List(), Expect: "price, qty"},
+		{Desc: "table columns keep generated", SQL: sb.SQLUtil.TableColumns(m, "id", "qty").List(), Expect: "price, total, label"},
+	}
+	for _, c := range cases {
+		if c.SQL != c.Expect {
+			t.Errorf("%s: expect %q, but got %q", c.Desc, c.Expect, c.SQL)
+		}
+	}
+
+	table, err := sb.SQLUtil.TableParser().StructTable(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Cols[4].Generated != "id || '-' || qty" || !table.Cols[4].Virtual {
+		t.Fatalf("unexpected generated column: %+v", table.Cols[4])
+	}
+	if _, err = sb.SQLUtil.CreateTableSQL(table); err == nil {
+		t.Fatal("postgres should reject virtual generated column")
+	}
+	s, err := NewSQLUtil(sb.SQLUtil.TableParser(), SQLite3{}).CreateTableSQL(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(s, `"total" INTEGER  GENERATED ALWAYS AS (price * qty) STORED,`) ||
+		!strings.Contains(s, `"label" TEXT  GENERATED ALWAYS AS (id || '-' || qty) VIRTUAL`) {
+		t.Fatalf("unexpected create table sql: %s", s)
+	}
+}
+
+func TestWriteFlags(t *testing.T) {
+	sb := newSQLBuilder()
+	type Audit struct {
+		Id        int64  `sqldb:"pk autoincr readonly"`
+		CreatedBy string `sqldb:"noupdate"`
+		Token     string `sqldb:"noinsert"`
+		Note      string
+	}
+	var m Audit
+	type testCase struct {
+		Desc   string
+		SQL    string
+		Expect string
+	}
+	cases := []testCase{
+		{Desc: "insert skips readonly and noinsert", SQL: sb.Insert(m), Expect: "INSERT INTO audit(created_by, note) VALUES(:created_by, :note)"},
+		{Desc: "update skips readonly and noupdate", SQL: sb.Update(m, nil, sb.WhereColumns("id")), Expect: "UPDATE audit SET token = :token, note = :note WHERE id = :id"},
+		{Desc: "listed noupdate column is not updated", SQL: sb.Update(m, []string{"created_by", "note"}, ""), Expect: "UPDATE audit SET note = :note"},
+		{Desc: "insert columns", SQL: sb.SQLUtil.InsertColumns(m, "note").List(), Expect: "created_by"},
+		{Desc: "update columns", SQL: sb.SQLUtil.UpdateColumns(m, "token").List(), Expect: "note"},
+		{Desc: "table columns keep all", SQL: sb.SQLUtil.TableColumns(m, "note").List(), Expect: "id, created_by, token"},
+	}
+	for _, c := range cases {
+		if c.SQL != c.Expect {
+			t.Errorf("%s: expect %q, but got %q", c.Desc, c.Expect, c.SQL)
+		}
+	}
+}
+
+func TestColumnGroups(t *testing.T) {
+	sb := newSQLBuilder()
+	type Article struct {
+		Id      string `sqldb:"pk group:summary,list"`
+		Title   string `sqldb:"group:summary group:list"`
+		Author  string `sqldb:"group:list"`
+		Content string
+	}
+	var m Article
+	if got := sb.Query(m, sb.SQLUtil.TableColumnsGroup(m, "summary"), ""); got != "SELECT id, title FROM article" {
+		t.Fatalf("unexpected query: %s", got)
+	}
+	cols, err := ColumnsGroupOf[Article](sb.SQLUtil, "list")
+	if err != nil || cols.List() != "id, title, author" {
+		t.Fatal("unexpected group columns", cols, err)
+	}
+	if _, err = ColumnsGroupOf[Article](sb.SQLUtil, "detail"); err == nil {
+		t.Fatal("expect error for unknown group")
+	}
+}
+
+// uninspectedPostgres is Postgres without schema inspection.
+type uninspectedPostgres struct{}
+
+func (uninspectedPostgres) Type(typ, precision, val string) (string, string, error) {
+	return Postgres{}.Type(typ, precision, val)
+}
+func (uninspectedPostgres) DSN(config DBConfig) string { return Postgres{}.DSN(config) }
+func (uninspectedPostgres) Features() DialectFeatures  { return Postgres{}.Features() }
+
+func TestCreateTablesOrder(t *testing.T) {
+	type Employee struct {
+		Id   int64 `sqldb:"pk"`
+		Dept int64 `sqldb:"fk:department.id"`
+	}
+	type Department struct {
+		Id      int64 `sqldb:"pk"`
+		Manager int64 `sqldb:"fk:employee.id"`
+	}
+	type Project struct {
+		Id   int64 `sqldb:"pk"`
+		Dept int64 `sqldb:"fk:department.id"`
+	}
+	su := NewSQLUtil(NewTableParser(), uninspectedPostgres{})
+	tables, err := su.modelTables([]interface{}{Project{}, Employee{}, Department{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables = sortTables(tables)
+	var names []string
+	for _, table := range tables {
+		names = append(names, table.Name)
+	}
+	if strings.Join(names, ",") != "employee,department,project" {
+		t.Fatal("unexpected order", names)
+	}
+	deferred := su.deferredForeignKeys(tables)
+	if len(deferred) != 1 || len(deferred["employee"]) != 1 || !deferred["employee"]["dept"] {
+		t.Fatal("unexpected deferred foreign keys", deferred)
+	}
+	createSQL, err := su.createTableSQL(tables[0], su.undeferredConstraints(tables[0], deferred["employee"]))
+	if err != nil || strings.Contains(createSQL, "FOREIGN KEY") {
+		t.Fatal("deferred foreign key should be skipped", createSQL, err)
+	}
+	stmts, err := su.deferredForeignKeySQL(context.Background(), nil, tables[0], deferred["employee"])
+	if err != nil || len(stmts) != 1 ||
+		stmts[0] != "ALTER TABLE \"employee\" ADD CONSTRAINT \"fk_employee_dept\" FOREIGN KEY (\"dept\") REFERENCES \"department\" (\"id\");\n" {
+		t.Fatalf("unexpected deferred statements: %q, %v", stmts, err)
+	}
+	if s := su.DropTableSQL(tables[0], true); s != "DROP TABLE IF EXISTS \"employee\" CASCADE;\n" {
+		t.Fatal("unexpected drop statement", s)
+	}
+	if deferred := NewSQLUtil(NewTableParser(), SQLite3{}).deferredForeignKeys(tables); len(deferred) != 0 {
+		t.Fatal("sqlite3 allows forward foreign keys", deferred)
+	}
+
+	type Author struct {
+		Id int64 `sqldb:"pk"`
+	}
+	type Book struct {
+		Id     int64 `sqldb:"pk"`
+		Author int64 `sqldb:"fk:author.id"`
+	}
+	db := openSQLite3(t)
+	if _, err = db.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		t.Fatal(err)
+	}
+	su = NewSQLUtil(NewTableParser(), SQLite3{})
+	if err = su.CreateTables(db, Book{}, Author{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.Exec("INSERT INTO author (id) VALUES (1); INSERT INTO book (id, author) VALUES (1, 1)"); err != nil {
+		t.Fatal(err)
+	}
+	if err = su.DropTables(db, false, Author{}, Book{}); err != nil {
+		t.Fatal(err)
+	}
+	if names, err := su.InspectSchema(context.Background(), db); err != nil || len(names) != 0 {
+		t.Fatal("tables should be dropped", names, err)
+	}
+}
+
+func TestCreateTablesContext(t *testing.T) {
+	type Author struct {
+		Id   int64  `sqldb:"pk"`
+		Name string `sqldb:"index"`
+	}
+	type Broken struct {
+		Id   int64  `sqldb:"pk"`
+		Name string `sqldb:"dbtype:'INTEGER CHECK ('"`
+	}
+	ctx := context.Background()
+	db := openSQLite3(t)
+	su := NewSQLUtil(NewTableParser(), SQLite3{})
+	tables := func() []TableSchema {
+		schemas, err := su.InspectSchema(ctx, db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return schemas
+	}
+
+	var buf strings.Builder
+	err := su.CreateTablesContext(ctx, nil, CreateTablesOptions{DryRun: &buf}, Author{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "CREATE TABLE IF NOT EXISTS \"author\"") ||
+		!strings.Contains(buf.String(), "CREATE INDEX IF NOT EXISTS \"ix_author_name\"") || len(tables()) != 0 {
+		t.Fatal("dry run should only write statements", buf.String())
+	}
+
+	if err = su.CreateTablesContext(ctx, db, CreateTablesOptions{}, Author{}, Broken{}); err == nil {
+		t.Fatal("expect create failure")
+	}
+	if len(tables()) != 0 {
+		t.Fatal("failed creation should be rolled back")
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = su.CreateTablesContext(ctx, conn, CreateTablesOptions{}, Author{})
+	conn.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schemas := tables(); len(schemas) != 1 || len(schemas[0].Indexes) != 1 {
+		t.Fatal("table should be created", schemas)
+	}
+
+	fileDB, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "create.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fileDB.Close()
+	err = su.WithLock(ctx, fileDB, defaultLockName, 0, func(*sql.Conn) error {
+		err := su.CreateTablesContext(ctx, fileDB, CreateTablesOptions{LockTimeout: time.Millisecond}, Author{})
+		if !errors.Is(err, ErrLockTimeout) {
+			t.Fatal("expect lock shared with migrator", err)
+		}
+		return su.CreateTablesContext(ctx, fileDB, CreateTablesOptions{NoLock: true}, Author{})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNaming(t *testing.T) {
+	type VeryLongModelNameForTestingIdentifierLimitsOfPostgresDialect struct {
+		Id    int64  `sqldb:"pk"`
+		Email string `sqldb:"unique index"`
+		Code  string `sqldb:"unique:uq_code"`
+		Owner int64  `sqldb:"fk:user.id"`
+	}
+	parser := NewTableParser()
+	table, err := parser.StructTable(VeryLongModelNameForTestingIdentifierLimitsOfPostgresDialect{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	su := NewSQLUtil(parser, Postgres{})
+	var names []string
+	for _, c := range su.tableConstraints(table) {
+		names = append(names, c.Name)
+	}
+	for _, index := range su.tableIndexes(table) {
+		names = append(names, index.Name)
+	}
+	if len(names) != 5 || names[2] != "uq_code" {
+		t.Fatal("unexpected names", names)
+	}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if len(name) > 63 || seen[name] {
+			t.Fatal("names should be shortened without collision", names)
+		}
+		seen[name] = true
+	}
+	if !strings.HasPrefix(names[0], "pk_very_long_model_name") || names[0] != su.identifier(su.naming.PrimaryKey(table.Name)) {
+		t.Fatal("unexpected primary key name", names[0])
+	}
+	if _, err = su.CreateTableSQL(table); err == nil {
+		t.Fatal("table name exceeding limit should fail")
+	}
+	if _, err = NewSQLUtil(parser, SQLite3{}).CreateTableSQL(table); err != nil {
+		t.Fatal("sqlite3 has no identifier limit", err)
+	}
+
+	type Short struct {
+		Id    int64  `sqldb:"pk"`
+		Email string `sqldb:"unique"`
+	}
+	table, err = parser.StructTable(Short{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	su.SetNaming(nil)
+	createSQL, err := su.CreateTableSQL(table)
+	if err != nil || strings.Contains(createSQL, "CONSTRAINT") || !strings.Contains(createSQL, `UNIQUE ("email")`) {
+		t.Fatal("constraints should be unnamed without naming strategy", createSQL, err)
+	}
+	type EventsOfVeryLongModelNameForTestingTriggerNames struct {
+		Id                                 int64     `sqldb:"pk"`
+		UpdatedAtOfVeryLongColumnNameAlpha time.Time `sqldb:"updated"`
+	}
+	type EventsOfVeryLongModelNameForTestingTriggerNames2 struct {
+		Id                                 int64     `sqldb:"pk"`
+		UpdatedAtOfVeryLongColumnNameAlpha time.Time `sqldb:"updated"`
+	}
+	var triggers []string
+	for _, model := range []interface{}{EventsOfVeryLongModelNameForTestingTriggerNames{}, EventsOfVeryLongModelNameForTestingTriggerNames2{}} {
+		table, err = parser.StructTable(model)
+		if err != nil {
+			t.Fatal(err)
+		}
+		name := su.identifier(DefaultNaming{}.Trigger(table.Name, table.Cols[1].Name))
+		stmts := su.UpdatedTriggerSQL(table)
+		if len(name) > 63 || len(stmts) != 3 || !strings.Contains(stmts[2], `CREATE TRIGGER "`+name+`"`) {
+			t.Fatal("trigger name should be shortened", name, stmts)
+		}
+		triggers = append(triggers, name)
+	}
+	if triggers[0] == triggers[1] {
+		t.Fatal("shortened trigger names collide", triggers)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 	"unicode"
 )
 
@@ -20,8 +21,20 @@ type Column struct {
 	DefaultVal   string
 	Unique       bool
 	UniqueName   string
+	Index        bool
+	IndexName    string
 	ForeignTable string
 	ForeignCol   string
+	SoftDelete   bool
+	Version      bool
+	Created      bool
+	Updated      bool
+	Generated    string
+	Virtual      bool
+	ReadOnly     bool
+	NoInsert     bool
+	NoUpdate     bool
+	Groups       []string
 
 	Field reflect.StructField
 }
@@ -32,6 +45,67 @@ type Table struct {
 	Type reflect.Type
 }
 
+// Insertable reports whether column is written by INSERT.
+func (c Column) Insertable() bool {
+	return c.Generated == "" && !c.ReadOnly && !c.NoInsert
+}
+
+// Updatable reports whether column is written by UPDATE by default.
+func (c Column) Updatable() bool {
+	return !c.immutable() && !c.Created && !c.SoftDelete
+}
+
+// immutable reports whether column is never written by UPDATE, even if it's
+// listed explicitly.
+func (c Column) immutable() bool {
+	return c.Generated != "" || c.ReadOnly || c.NoUpdate
+}
+
+func (c Column) InGroup(group string) bool {
+	for _, g := range c.Groups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+func (t Table) Col(name string) (Column, bool) {
+	for _, c := range t.Cols {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Column{}, false
+}
+
+func (t Table) SoftDeleteCol() (Column, bool) {
+	for _, c := range t.Cols {
+		if c.SoftDelete {
+			return c, true
+		}
+	}
+	return Column{}, false
+}
+
+func (t Table) VersionCol() (Column, bool) {
+	for _, c := range t.Cols {
+		if c.Version {
+			return c, true
+		}
+	}
+	return Column{}, false
+}
+
+func (t Table) UpdatedCol() (Column, bool) {
+	for _, c := range t.Cols {
+		if c.Updated {
+			return c, true
+		}
+	}
+	return Column{}, false
+}
+
 type TableParserOptions struct {
 	FieldTag        string
 	ColumnNameTag   string
@@ -39,6 +113,7 @@ type TableParserOptions struct {
 	Notnull         bool
 	TablenamePrefix string
 	NameMapper      NameMapper
+	Mappings        TableMappings
 }
 
 func (o *TableParserOptions) merge(opts ...TableParserOptions) {
@@ -61,14 +136,21 @@ func (o *TableParserOptions) merge(opts ...TableParserOptions) {
 		if opt.NameMapper != nil {
 			o.NameMapper = opt.NameMapper
 		}
+		for name, m := range opt.Mappings {
+			if o.Mappings == nil {
+				o.Mappings = make(TableMappings)
+			}
+			o.Mappings[name] = m
+		}
 	}
 }
 
 type TableParser struct {
 	opts TableParserOptions
 
-	mu     sync.RWMutex
-	tables map[reflect.Type]Table
+	mu         sync.RWMutex
+	tables     map[reflect.Type]Table
+	registered []reflect.Type
 }
 
 func NewTableParser(options ...TableParserOptions) *TableParser {
@@ -85,95 +167,203 @@ func NewTableParser(options ...TableParserOptions) *TableParser {
 	}
 }
 
-func (p *TableParser) parseColumn(t *Table, f reflect.StructField) (Column, error) {
+type tagCond struct {
+	Name string
+	Val  string
+}
+
+// splitTagConds splits tag into conditions separated by space, value quoted
+// by single quotes may contain spaces, and doubled quote in it is escaped.
+func (p *TableParser) splitTagConds(tag string) ([]tagCond, error) {
+	var (
+		conds []tagCond
+		sec   []rune
+		quote bool
+		runes = []rune(tag)
+	)
+	flush := func() {
+		if len(sec) == 0 {
+			return
+		}
+		keyCond := strings.SplitN(string(sec), ":", 2)
+		cond := tagCond{Name: keyCond[0]}
+		if len(keyCond) > 1 {
+			cond.Val = keyCond[1]
+		}
+		conds = append(conds, cond)
+		sec = sec[:0]
+	}
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote && r == '\'':
+			if i+1 < len(runes) && runes[i+1] == '\'' {
+				sec = append(sec, r)
+				i++
+			} else {
+				quote = false
+			}
+		case quote:
+			sec = append(sec, r)
+		case r == '\'' && i > 0 && runes[i-1] == ':':
+			quote = true
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			sec = append(sec, r)
+		}
+	}
+	if quote {
+		return nil, fmt.Errorf("unterminated quote: %s", tag)
+	}
+	flush()
+	return conds, nil
+}
+
+func (p *TableParser) applyCond(t *Table, col *Column, cond tagCond) error {
+	condName, condVal := cond.Name, cond.Val
+	switch condName {
+	case "table":
+		t.Name = condVal
+	case "col":
+		if condVal == "" {
+			return fmt.Errorf("invalid column name")
+		}
+		if condVal == "-" {
+			col.Name = ""
+			return nil
+		}
+		col.Name = condVal
+	case "type":
+		if condVal == "" {
+			return fmt.Errorf("invalid column type: %s", col.Name)
+		}
+		col.Type = condVal
+	case "precision":
+		if condVal == "" {
+			return fmt.Errorf("invalid column precision: %s", col.Name)
+		}
+		col.Precision = condVal
+	case "dbtype":
+		if condVal == "" {
+			return fmt.Errorf("invalid column db type: %s", col.Name)
+		}
+		col.DBType = condVal
+	case "pk":
+		col.Primary = condVal == "" || condVal == "true"
+	case "autoincr":
+		col.AutoIncr = condVal == "" || condVal == "true"
+	case "notnull":
+		col.Notnull = condVal == "" || condVal == "true"
+	case "default":
+		col.Default = condVal != "-"
+		if p.opts.Default {
+			col.DefaultVal = condVal
+		}
+	case "unique":
+		col.Unique = true
+		col.UniqueName = condVal
+	case "generated":
+		if condVal == "" {
+			return fmt.Errorf("invalid generated expression: %s", col.Name)
+		}
+		col.Generated = condVal
+		col.Default = false
+	case "virtual":
+		col.Virtual = condVal == "" || condVal == "true"
+	case "readonly":
+		col.ReadOnly = condVal == "" || condVal == "true"
+	case "noinsert":
+		col.NoInsert = condVal == "" || condVal == "true"
+	case "noupdate":
+		col.NoUpdate = condVal == "" || condVal == "true"
+	case "group":
+		if condVal == "" {
+			return fmt.Errorf("invalid column group: %s", col.Name)
+		}
+		col.Groups = append(col.Groups, strings.Split(condVal, ",")...)
+	case "created":
+		col.Created = condVal == "" || condVal == "true"
+	case "updated":
+		col.Updated = condVal == "" || condVal == "true"
+	case "version":
+		col.Version = condVal == "" || condVal == "true"
+	case "softdelete":
+		col.SoftDelete = condVal == "" || condVal == "true"
+	case "index":
+		col.Index = true
+		col.IndexName = condVal
+	case "fk":
+		fkConds := strings.SplitN(condVal, ".", 2)
+		if len(fkConds) != 2 || fkConds[0] == "" || fkConds[1] == "" {
+			return fmt.Errorf("invalid foreign key: %s", condVal)
+		}
+		col.ForeignTable = fkConds[0]
+		col.ForeignCol = fkConds[1]
+	default:
+		return fmt.Errorf("unsupported tag: %s", condName)
+	}
+	return nil
+}
+
+func (p *TableParser) parseColumn(t *Table, f reflect.StructField, mapping *ColumnMapping) (Column, error) {
+	typ, nullable := p.columnType(f.Type)
 	col := Column{
 		Name:    p.opts.NameMapper(f.Name),
-		Type:    f.Type.String(),
-		Default: p.opts.Default,
-		Notnull: !p.opts.Notnull,
+		Type:    typ,
+		Default: p.opts.Default && !nullable,
+		Notnull: p.opts.Notnull && !nullable,
 		Field:   f,
 	}
-	if p.isBlob(f.Type) {
-		col.Type = "blob"
-	}
 	if p.opts.ColumnNameTag != "" {
 		tag := f.Tag.Get(p.opts.ColumnNameTag)
 		if tag != "" {
 			col.Name = tag
 		}
 	}
-	var conds []string
-	tag := strings.TrimSpace(f.Tag.Get(p.opts.FieldTag))
-	if tag != "" {
-		conds = strings.Split(tag, " ")
-	}
-	for _, sec := range conds {
-		sec = strings.TrimSpace(sec)
-		var (
-			keyCond  = strings.SplitN(sec, ":", 2)
-			condName = keyCond[0]
-			condVal  string
-		)
-		if len(keyCond) > 1 {
-			condVal = keyCond[1]
-		}
-
-		switch condName {
-		case "table":
-			t.Name = condVal
-		case "col":
-			col.Name = condVal
-			if condVal == "" {
-				return col, fmt.Errorf("invalid column name")
-			}
-			if condVal == "-" {
-				col.Name = ""
-				return col, nil
-			}
-			col.Name = condVal
-		case "type":
-			if condVal == "" {
-				return col, fmt.Errorf("invalid column type: %s", col.Name)
+	if mapping != nil {
+		for _, cond := range mapping.conds() {
+			if err := p.applyCond(t, &col, cond); err != nil {
+				return col, fmt.Errorf("%s.%s: mapping: %s", t.Type.Name(), f.Name, err.Error())
 			}
-			col.Type = condVal
-		case "precision":
-			if condVal == "" {
-				return col, fmt.Errorf("invalid column precision: %s", col.Name)
+			// col:- skips column, tags may still name it
+			if col.Name == "" {
+				break
 			}
-			col.Precision = condVal
-		case "dbtype":
-			if condVal == "" {
-				return col, fmt.Errorf("invalid column db type: %s", col.Name)
-			}
-			col.DBType = condVal
-		case "pk":
-			col.Primary = condVal == "" || condVal == "true"
-		case "autoincr":
-			col.AutoIncr = condVal == "" || condVal == "true"
-		case "notnull":
-			col.Notnull = condVal == "" || condVal == "true"
-		case "default":
-			col.Default = condVal != "-"
-			if p.opts.Default {
-				col.DefaultVal = condVal
-			}
-		case "unique":
-			col.Unique = true
-			col.UniqueName = condVal
-		case "fk":
-			fkConds := strings.SplitN(condVal, ".", 2)
-			if len(fkConds) != 2 || fkConds[0] == "" || fkConds[1] == "" {
-				return col, fmt.Errorf("invalid foreign key: %s", condVal)
-			}
-			col.ForeignTable = fkConds[0]
-			col.ForeignCol = fkConds[1]
-		default:
-			return col, fmt.Errorf("unsupported tag: %s", condName)
 		}
 	}
+	conds, err := p.splitTagConds(f.Tag.Get(p.opts.FieldTag))
+	if err != nil {
+		return col, fmt.Errorf("%s.%s: tag: %s", t.Type.Name(), f.Name, err.Error())
+	}
+	for _, cond := range conds {
+		if err := p.applyCond(t, &col, cond); err != nil {
+			return col, fmt.Errorf("%s.%s: tag: %s", t.Type.Name(), f.Name, err.Error())
+		}
+		if col.Name == "" {
+			return col, nil
+		}
+	}
+	if col.Name == "" {
+		return col, nil
+	}
+	if col.SoftDelete && !(col.Type == "bool" || (col.Type == "time" && !col.Notnull)) {
+		return col, fmt.Errorf("%s.%s: softdelete column must be bool or nullable time", t.Type.Name(), f.Name)
+	}
+	if col.Virtual && col.Generated == "" {
+		return col, fmt.Errorf("%s.%s: virtual column must be generated", t.Type.Name(), f.Name)
+	}
+	if (col.Created || col.Updated) && col.Type != "time" {
+		return col, fmt.Errorf("%s.%s: created and updated column must be time", t.Type.Name(), f.Name)
+	}
+	if col.Version && !strings.Contains(col.Type, "int") {
+		return col, fmt.Errorf("%s.%s: version column must be integer", t.Type.Name(), f.Name)
+	}
 	return col, nil
 }
 
+var timeType = reflect.TypeOf(time.Time{})
+
 func (p *TableParser) isPrimary(t reflect.Type) bool {
 	switch t.Kind() {
 	case reflect.Bool,
@@ -199,14 +389,42 @@ func (p *TableParser) isBlob(t reflect.Type) bool {
 	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
 }
 
+func (p *TableParser) isTime(t reflect.Type) bool {
+	return t == timeType
+}
+
+func (p *TableParser) isColumnType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return p.isPrimary(t) || p.isBlob(t) || p.isTime(t)
+}
+
+// columnType returns the column type of field type, pointers are nullable.
+func (p *TableParser) columnType(t reflect.Type) (typ string, nullable bool) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+	switch {
+	case p.isTime(t):
+		return "time", nullable
+	case p.isBlob(t):
+		return "blob", nullable
+	case p.isPrimary(t):
+		return t.Kind().String(), nullable
+	}
+	return t.String(), nullable
+}
+
 func (p *TableParser) shouldIgnore(f *reflect.StructField) bool {
 	if f.Tag.Get(p.opts.FieldTag) == "-" {
 		return true
 	}
-	if f.Type.Kind() == reflect.Struct {
+	if f.Type.Kind() == reflect.Struct && !p.isTime(f.Type) {
 		return !f.Anonymous
 	}
-	if !p.isPrimary(f.Type) && !p.isBlob(f.Type) {
+	if !p.isColumnType(f.Type) {
 		return true
 	}
 	return unicode.IsLower([]rune(f.Name)[0])
@@ -231,7 +449,7 @@ func (p *TableParser) structFields(fields []reflect.StructField, parentFieldInde
 		if p.shouldIgnore(&f) {
 			continue
 		}
-		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && !p.isTime(f.Type) {
 			anonymousStructs = append(anonymousStructs, f)
 		} else {
 			var override bool
@@ -253,17 +471,15 @@ func (p *TableParser) structFields(fields []reflect.StructField, parentFieldInde
 	return fields
 }
 
-func (p *TableParser) indirectReflect(v interface{}) reflect.Value {
-	refv := reflect.ValueOf(v)
-	for refv.Kind() == reflect.Ptr {
-		refv = refv.Elem()
+func (p *TableParser) indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
 	}
-	return refv
+	return t
 }
 
-func (p *TableParser) parseTable(refv reflect.Value) (Table, error) {
-	reft := refv.Type()
-	if refv.Kind() != reflect.Struct {
+func (p *TableParser) parseTable(reft reflect.Type) (Table, error) {
+	if reft.Kind() != reflect.Struct {
 		return Table{}, fmt.Errorf("invalid artument type, expect (pointer of) structure")
 	}
 
@@ -271,9 +487,17 @@ func (p *TableParser) parseTable(refv reflect.Value) (Table, error) {
 		Name: p.opts.TablenamePrefix + p.opts.NameMapper(reft.Name()),
 		Type: reft,
 	}
+	mapping, hasMapping := p.opts.Mappings.lookup(reft)
+	if hasMapping && mapping.Table != "" {
+		t.Name = mapping.Table
+	}
 	fields := p.structFields(nil, nil, reft)
 	for _, f := range fields {
-		col, err := p.parseColumn(&t, f)
+		var colMapping *ColumnMapping
+		if m, has := mapping.Columns[f.Name]; has {
+			colMapping = &m
+		}
+		col, err := p.parseColumn(&t, f, colMapping)
 		if err != nil {
 			return t, err
 		}
@@ -281,12 +505,31 @@ func (p *TableParser) parseTable(refv reflect.Value) (Table, error) {
 			t.Cols = append(t.Cols, col)
 		}
 	}
+	for name := range mapping.Columns {
+		var found bool
+		for _, f := range fields {
+			if f.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return t, fmt.Errorf("%s.%s: mapping: unknown or ignored field", reft.Name(), name)
+		}
+	}
 	return t, nil
 }
 
 func (p *TableParser) StructTable(v interface{}) (Table, error) {
-	refv := p.indirectReflect(v)
-	reft := refv.Type()
+	if v == nil {
+		return Table{}, fmt.Errorf("invalid artument type, expect (pointer of) structure")
+	}
+	return p.TypeTable(reflect.TypeOf(v))
+}
+
+// TypeTable is like StructTable but needs no value of the type.
+func (p *TableParser) TypeTable(reft reflect.Type) (Table, error) {
+	reft = p.indirectType(reft)
 
 	p.mu.RLock()
 	t, has := p.tables[reft]
@@ -295,7 +538,7 @@ func (p *TableParser) StructTable(v interface{}) (Table, error) {
 		return t, nil
 	}
 
-	t, err := p.parseTable(refv)
+	t, err := p.parseTable(reft)
 	if err != nil {
 		return t, err
 	}
@@ -308,6 +551,11 @@ func (p *TableParser) StructTable(v interface{}) (Table, error) {
 	return t, nil
 }
 
+// TableOf returns the table of structure type T, or pointer of it.
+func TableOf[T any](p *TableParser) (Table, error) {
+	return p.TypeTable(reflect.TypeOf((*T)(nil)).Elem())
+}
+
 func SnakeCase(s string) string {
 	runes := []rune(s)
 
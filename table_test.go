@@ -2,7 +2,9 @@ package sqldb
 
 import (
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestSQLCreate(t *testing.T) {
@@ -48,8 +50,9 @@ func TestParse(t *testing.T) {
 		V2 string
 	}
 	type Stru struct {
-		Val   bool
-		Bytes []byte
+		Val     bool
+		Bytes   []byte
+		Skipped string `sqldb:"col:- softdelete fk:invalid"`
 		ExportedStr
 		unexportedStr
 		ExportedEmbed
@@ -76,3 +79,268 @@ func TestParse(t *testing.T) {
 		}
 	}
 }
+
+func TestParseMappings(t *testing.T) {
+	type Generated struct {
+		Id    int64
+		Email string
+		Owner int64 `sqldb:"col:owner"`
+	}
+	def := "x"
+	unique := ""
+	parser := NewTableParser(TableParserOptions{
+		Default: true,
+		Mappings: TableMappings{
+			"Generated": {
+				Table: "generated_models",
+				Columns: map[string]ColumnMapping{
+					"Id":    {PK: true, AutoIncr: true},
+					"Email": {Col: "mail", Unique: &unique, Default: &def},
+					"Owner": {Col: "owner_id", FK: "user.id"},
+				},
+			},
+		},
+	})
+	table, err := parser.StructTable(Generated{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Name != "generated_models" {
+		t.Fatalf("unexpected table name: %s", table.Name)
+	}
+	id, email, owner := table.Cols[0], table.Cols[1], table.Cols[2]
+	if !id.Primary || !id.AutoIncr {
+		t.Fatal("mapping pk/autoincr not applied")
+	}
+	if email.Name != "mail" || !email.Unique || email.UniqueName != "" || email.DefaultVal != "x" {
+		t.Fatalf("mapping not applied: %+v", email)
+	}
+	if owner.Name != "owner" || owner.ForeignTable != "user" || owner.ForeignCol != "id" {
+		t.Fatalf("tag should win over mapping: %+v", owner)
+	}
+
+	parser = NewTableParser(TableParserOptions{
+		Mappings: TableMappings{
+			"Generated": {Columns: map[string]ColumnMapping{"Owner": {FK: "user"}}},
+		},
+	})
+	_, err = parser.StructTable(Generated{})
+	if err == nil || !strings.Contains(err.Error(), "Generated.Owner: mapping:") {
+		t.Fatalf("expect mapping error, got %v", err)
+	}
+	parser = NewTableParser(TableParserOptions{
+		Mappings: TableMappings{
+			"Generated": {Columns: map[string]ColumnMapping{"Missing": {PK: true}}},
+		},
+	})
+	if _, err = parser.StructTable(Generated{}); err == nil {
+		t.Fatal("expect unknown field error")
+	}
+
+	parser = NewTableParser(TableParserOptions{
+		Mappings: TableMappings{
+			"Generated": {Columns: map[string]ColumnMapping{"Email": {Col: "-", FK: "invalid"}}},
+		},
+	})
+	table, err = parser.StructTable(Generated{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, has := table.Col("email"); has || len(table.Cols) != 2 {
+		t.Fatalf("expect email skipped by mapping: %+v", table.Cols)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	type User struct {
+		Id    int64  `sqldb:"pk"`
+		Email string `sqldb:"unique:email"`
+	}
+	type Post struct {
+		Id     int64 `sqldb:"pk"`
+		UserId int64 `sqldb:"fk:user.id"`
+	}
+	type Comment struct {
+		Id     int64 `sqldb:"pk"`
+		PostId int64 `sqldb:"fk:post.id"`
+	}
+	parser := NewTableParser()
+	if err := parser.Register(Comment{}, Post{}, User{}); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, table := range parser.Tables() {
+		names = append(names, table.Name)
+	}
+	if strings.Join(names, ",") != "user,post,comment" {
+		t.Fatalf("unexpected table order: %v", names)
+	}
+
+	type Account struct {
+		Id    int64  `sqldb:"pk table:user"`
+		Email string `sqldb:"unique:email"`
+	}
+	if err := parser.Register(Account{}); err == nil || !strings.Contains(err.Error(), "table user") {
+		t.Fatalf("expect table collision, got %v", err)
+	}
+	if len(parser.Tables()) != 3 {
+		t.Fatal("failed registration should not change registered set")
+	}
+
+	type Tag struct {
+		Name   string `sqldb:"unique:email"`
+		PostId int64  `sqldb:"fk:article.id"`
+	}
+	err := parser.Register(Tag{})
+	if err == nil || !strings.Contains(err.Error(), "constraint email") || !strings.Contains(err.Error(), "article") {
+		t.Fatalf("expect constraint and foreign key errors, got %v", err)
+	}
+}
+
+func TestCheckNames(t *testing.T) {
+	type Author struct {
+		Id    int64  `sqldb:"pk"`
+		Name  string `sqldb:"index:ix_name"`
+		Email string `sqldb:"unique:uq_reader_email"`
+	}
+	type Reader struct {
+		Id    int64  `sqldb:"pk"`
+		Name  string `sqldb:"index:ix_name"`
+		Email string `sqldb:"unique"`
+	}
+	parser := NewTableParser()
+	var tables []Table
+	for _, model := range []interface{}{Author{}, Reader{}} {
+		table, err := parser.StructTable(model)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tables = append(tables, table)
+	}
+	postgres := NewSQLUtil(parser, Postgres{})
+	err := postgres.CheckNames(tables...)
+	if err == nil || !strings.Contains(err.Error(), "name ix_name") || !strings.Contains(err.Error(), "name uq_reader_email") {
+		t.Fatalf("expect explicit and generated names to collide, got %v", err)
+	}
+	if _, err = postgres.CreateTablesSQL(tables...); err == nil {
+		t.Fatal("expect collision error creating tables")
+	}
+	// index names of MySQL are scoped to tables
+	if err = NewSQLUtil(parser, MySQL{}).CheckNames(tables...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNullable(t *testing.T) {
+	type Model struct {
+		Name      string `sqldb:"notnull"`
+		Nick      string
+		Age       *int
+		DeletedAt *time.Time
+	}
+	sqlUtil := NewSQLUtil(NewTableParser(), Postgres{})
+	table, err := sqlUtil.TableParser().StructTable(Model{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]bool{"name": true, "nick": false, "age": false, "deleted_at": false}
+	for _, col := range table.Cols {
+		if col.Notnull != expect[col.Name] {
+			t.Errorf("%s: expect notnull %t", col.Name, expect[col.Name])
+		}
+	}
+	if table.Cols[2].Type != "int" || table.Cols[3].Type != "time" {
+		t.Fatalf("unexpected column types: %s, %s", table.Cols[2].Type, table.Cols[3].Type)
+	}
+	s, err := sqlUtil.CreateTableSQL(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(s, `"name" VARCHAR(64)  NOT NULL`) || strings.Count(s, "NOT NULL") != 1 {
+		t.Fatalf("unexpected create table sql: %s", s)
+	}
+}
+
+func TestColumnTypes(t *testing.T) {
+	type Status int
+	type Model struct {
+		Name    string
+		Status  Status
+		Tagged  string `sqldb:"notnull"`
+		Age     *int
+		Created time.Time
+	}
+	type testCase struct {
+		Opts TableParserOptions
+
+		Notnull map[string]bool
+		DDL     []string
+	}
+	cases := []testCase{
+		// unchanged: columns are nullable by default, but notnull tag was
+		// inverted and rendered a nullable column
+		{
+			Notnull: map[string]bool{"tagged": true},
+			DDL:     []string{`"name" VARCHAR(64) ,`, `"status" BIGINT ,`, `"tagged" VARCHAR(64)  NOT NULL,`, `"age" BIGINT ,`, `"created" TIMESTAMP WITH TIME ZONE ` + "\n"},
+		},
+		// unchanged: Notnull option makes columns NOT NULL, except pointers
+		// which are nullable now
+		{
+			Opts:    TableParserOptions{Notnull: true},
+			Notnull: map[string]bool{"name": true, "status": true, "tagged": true, "created": true},
+			DDL:     []string{`"name" VARCHAR(64)  NOT NULL,`, `"status" BIGINT  NOT NULL,`, `"tagged" VARCHAR(64)  NOT NULL,`, `"age" BIGINT ,`, `"created" TIMESTAMP WITH TIME ZONE  NOT NULL` + "\n"},
+		},
+	}
+	for i, c := range cases {
+		sqlUtil := NewSQLUtil(NewTableParser(c.Opts), Postgres{})
+		table, err := sqlUtil.TableParser().StructTable(Model{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// named types were mapped to their type name, such as sqldb.Status,
+		// which no dialect supports, and time.Time was skipped.
+		types := map[string]string{"name": "string", "status": "int", "tagged": "string", "age": "int", "created": "time"}
+		for _, col := range table.Cols {
+			if col.Type != types[col.Name] {
+				t.Errorf("%d: %s: expect type %s, but got %s", i, col.Name, types[col.Name], col.Type)
+			}
+			if col.Notnull != c.Notnull[col.Name] {
+				t.Errorf("%d: %s: expect notnull %t", i, col.Name, c.Notnull[col.Name])
+			}
+		}
+		s, err := sqlUtil.CreateTableSQL(table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, def := range c.DDL {
+			if !strings.Contains(s, def) {
+				t.Errorf("%d: expect %q in create table sql: %s", i, def, s)
+			}
+		}
+	}
+}
+
+func TestTimeDefault(t *testing.T) {
+	type Model struct {
+		Created time.Time `sqldb:"default"`
+		Since   time.Time `sqldb:"default:2020-01-01"`
+		Until   time.Time `sqldb:"default:'2030-01-01 00:00:00'"`
+		Touched time.Time `sqldb:"default:now()"`
+	}
+	parser := NewTableParser(TableParserOptions{Default: true})
+	table, err := parser.StructTable(Model{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dialect := range []DBDialect{Postgres{}, SQLite3{}, MySQL{}} {
+		s, err := NewSQLUtil(parser, dialect).CreateTableSQL(table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, def := range []string{"DEFAULT CURRENT_TIMESTAMP", "DEFAULT '2020-01-01'", "DEFAULT '2030-01-01 00:00:00'", "DEFAULT now()"} {
+			if !strings.Contains(s, def) {
+				t.Errorf("%T: expect %q in create table sql: %s", dialect, def, s)
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package sqldb
+
+import (
+	"fmt"
+	"strings"
+)
+
+// conflictColumns returns the conflict target of upsert. It's cols if they
+// are columns of table, or the columns of the unique constraint named by the
+// only element of cols. If cols is empty, it's the primary key, or the only
+// unique constraint of table.
+func (b *SQLBuilder) conflictColumns(table Table, cols []string) ([]string, error) {
+	var uniques []Constraint
+	for _, c := range b.SQLUtil.tableConstraints(table) {
+		if c.Type == ConstraintPrimaryKey && len(cols) == 0 {
+			return c.Cols, nil
+		}
+		if c.Type == ConstraintUnique {
+			uniques = append(uniques, c)
+		}
+	}
+	if len(cols) == 0 {
+		if len(uniques) != 1 {
+			return nil, fmt.Errorf("%s: conflict columns are required without primary key or the only unique constraint", table.Name)
+		}
+		return uniques[0].Cols, nil
+	}
+	if _, has := table.Col(cols[0]); !has && len(cols) == 1 {
+		for _, c := range uniques {
+			if c.Name == cols[0] || c.Name == b.SQLUtil.identifier(cols[0]) {
+				return c.Cols, nil
+			}
+		}
+	}
+	for _, col := range cols {
+		if _, has := table.Col(col); !has {
+			return nil, fmt.Errorf("%s: conflict column %s is not defined", table.Name, col)
+		}
+	}
+	return cols, nil
+}
+
+func (b *SQLBuilder) upsert(model interface{}, conflictCols, updateCols []string, nothing bool) (string, error) {
+	table, err := b.SQLUtil.parser.StructTable(model)
+	if err != nil {
+		return "", err
+	}
+	conflictCols, err = b.conflictColumns(table, conflictCols)
+	if err != nil {
+		return "", err
+	}
+	explicit := len(updateCols) > 0
+	if !nothing && !explicit {
+		// rewriting the primary key of the conflicting row is never intended
+		excepts := append([]string(nil), conflictCols...)
+		for _, col := range table.Cols {
+			if col.Primary {
+				excepts = append(excepts, col.Name)
+			}
+		}
+		updateCols = b.SQLUtil.updateColumns(table, excepts)
+	}
+	var sets []string
+	for _, name := range updateCols {
+		col, has := table.Col(name)
+		if !has {
+			return "", fmt.Errorf("%s: update column %s is not defined", table.Name, name)
+		}
+		if col.immutable() || col.Version {
+			continue
+		}
+		sets = append(sets, name)
+	}
+	if explicit && len(sets) == 0 {
+		return "", fmt.Errorf("%s: update columns %s are not updatable", table.Name, strings.Join(updateCols, ", "))
+	}
+
+	version, hasVersion := table.VersionCol()
+	hasVersion = hasVersion && len(sets) > 0
+
+	columns := b.SQLUtil.insertColumns(table, nil)
+	insert := fmt.Sprintf("INSERT INTO %s(%s) VALUES(%s)", table.Name, columns.List(), b.insertValues(table, columns))
+	if dialectFeatures(b.SQLUtil.dialect).Upsert == UpsertOnDuplicateKey {
+		if len(sets) == 0 {
+			// assigning a column to itself changes nothing
+			return insert + " ON DUPLICATE KEY UPDATE " + conflictCols[0] + " = " + conflictCols[0], nil
+		}
+		for i, col := range sets {
+			sets[i] = col + " = VALUES(" + col + ")"
+		}
+		if hasVersion {
+			sets = append(sets, version.Name+" = "+version.Name+" + 1")
+		}
+		return insert + " ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", "), nil
+	}
+	insert += " ON CONFLICT (" + strings.Join(conflictCols, ", ") + ")"
+	if len(sets) == 0 {
+		return insert + " DO NOTHING", nil
+	}
+	for i, col := range sets {
+		sets[i] = col + " = EXCLUDED." + col
+	}
+	if hasVersion {
+		sets = append(sets, version.Name+" = "+table.Name+"."+version.Name+" + 1")
+	}
+	return insert + " DO UPDATE SET " + strings.Join(sets, ", "), nil
+}
+
+// Upsert is like Insert but updates updateCols of the conflicting row, they
+// default to the updatable columns except conflictCols and the primary key. conflictCols default
+// to the primary key or the only unique constraint, a single unique
+// constraint name is also accepted. MySQL ignores them and applies to
+// conflicts of any unique key. The version column is increased on update.
+func (b *SQLBuilder) Upsert(model interface{}, conflictCols, updateCols []string) (string, error) {
+	return b.upsert(model, conflictCols, updateCols, false)
+}
+
+// InsertOrIgnore is like Upsert but leaves the conflicting row unchanged.
+func (b *SQLBuilder) InsertOrIgnore(model interface{}, conflictCols []string) (string, error) {
+	return b.upsert(model, conflictCols, nil, true)
+}
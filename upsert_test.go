@@ -0,0 +1,146 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestUpsert(t *testing.T) {
+	type Account struct {
+		Id      int64  `sqldb:"pk"`
+		Email   string `sqldb:"unique:uq_account_email"`
+		Name    string
+		Ver     int       `sqldb:"version"`
+		Created time.Time `sqldb:"created"`
+		Updated time.Time `sqldb:"updated"`
+	}
+	type Tag struct {
+		Name  string `sqldb:"unique"`
+		Count int
+	}
+	type Log struct {
+		Message string
+	}
+	var (
+		p        = NewTableParser()
+		postgres = NewSQLBuilder(NewSQLUtil(p, Postgres{}))
+		mysql    = NewSQLBuilder(NewSQLUtil(p, MySQL{}))
+		sqlite3  = NewSQLBuilder(NewSQLUtil(p, SQLite3{}))
+		a        Account
+	)
+	const insertAccount = "INSERT INTO account(id, email, name, ver, created, updated) VALUES(:id, :email, :name, :ver, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)"
+	type testCase struct {
+		Builder          *SQLBuilder
+		Model            interface{}
+		Nothing          bool
+		Conflict, Update []string
+
+		Expect string
+	}
+	cases := []testCase{
+		{
+			Builder: postgres, Model: a,
+			Expect: insertAccount + " ON CONFLICT (id) DO UPDATE SET email = EXCLUDED.email, name = EXCLUDED.name, updated = EXCLUDED.updated, ver = account.ver + 1",
+		},
+		{
+			Builder: sqlite3, Model: a, Conflict: []string{"uq_account_email"}, Update: []string{"name"},
+			Expect: insertAccount + " ON CONFLICT (email) DO UPDATE SET name = EXCLUDED.name, ver = account.ver + 1",
+		},
+		{
+			Builder: mysql, Model: a, Update: []string{"name"},
+			Expect: insertAccount + " ON DUPLICATE KEY UPDATE name = VALUES(name), ver = ver + 1",
+		},
+		{
+			Builder: postgres, Model: a, Conflict: []string{"email"},
+			Expect: insertAccount + " ON CONFLICT (email) DO UPDATE SET name = EXCLUDED.name, updated = EXCLUDED.updated, ver = account.ver + 1",
+		},
+		{
+			Builder: postgres, Model: a, Conflict: []string{"email"}, Nothing: true,
+			Expect: insertAccount + " ON CONFLICT (email) DO NOTHING",
+		},
+		{
+			Builder: mysql, Model: a, Nothing: true,
+			Expect: insertAccount + " ON DUPLICATE KEY UPDATE id = id",
+		},
+		{
+			Builder: postgres, Model: Tag{},
+			Expect: "INSERT INTO tag(name, count) VALUES(:name, :count) ON CONFLICT (name) DO UPDATE SET count = EXCLUDED.count",
+		},
+	}
+	for i, c := range cases {
+		var (
+			got string
+			err error
+		)
+		if c.Nothing {
+			got, err = c.Builder.InsertOrIgnore(c.Model, c.Conflict)
+		} else {
+			got, err = c.Builder.Upsert(c.Model, c.Conflict, c.Update)
+		}
+		if err != nil {
+			t.Fatalf("%d: %s", i, err)
+		}
+		if got != c.Expect {
+			t.Errorf("%d: expect %q, but got %q", i, c.Expect, got)
+		}
+	}
+
+	if _, err := postgres.Upsert(Log{}, nil, nil); err == nil {
+		t.Error("expect error without conflict columns")
+	}
+	if _, err := postgres.Upsert(a, []string{"mail"}, nil); err == nil {
+		t.Error("expect error for undefined conflict column")
+	}
+	if _, err := postgres.Upsert(a, nil, []string{"nmae"}); err == nil {
+		t.Error("expect error for undefined update column")
+	}
+	if _, err := postgres.Upsert(a, nil, []string{"ver"}); err == nil {
+		t.Error("expect error for no updatable column")
+	}
+}
+
+func TestUpsertExec(t *testing.T) {
+	type Counter struct {
+		Name  string `sqldb:"pk"`
+		Count int
+	}
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	b := NewSQLBuilder(NewSQLUtil(NewTableParser(), SQLite3{}))
+	if err = b.SQLUtil.CreateTables(db, Counter{}); err != nil {
+		t.Fatal(err)
+	}
+	upsert, err := b.Upsert(Counter{}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ignore, err := b.InsertOrIgnore(Counter{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, s := range []struct {
+		query string
+		count int
+	}{{upsert, 1}, {upsert, 2}, {ignore, 3}} {
+		query, args, err := b.SQLUtil.BindNamed(s.query, Counter{Name: "hits", Count: s.count})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = db.ExecContext(ctx, query, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var count int
+	if err = db.QueryRow("SELECT count FROM counter WHERE name = 'hits'").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("expect count 2, but got %d", count)
+	}
+}
@@ -0,0 +1,128 @@
+package sqldb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+const (
+	ProblemMissingTable  = "missing table"
+	ProblemMissingColumn = "missing column"
+	// ProblemRequiredColumn is a NOT NULL column without default which isn't
+	// written by SQLBuilder.Insert, so inserts fail.
+	ProblemRequiredColumn = "required column"
+	ProblemTypeMismatch   = "type mismatch"
+	ProblemNullMismatch   = "nullability mismatch"
+)
+
+// SchemaProblem is an incompatibility between a model and the live schema.
+type SchemaProblem struct {
+	Kind   string
+	Table  string
+	Column string
+	Detail string
+}
+
+func (p SchemaProblem) String() string {
+	s := p.Table
+	if p.Column != "" {
+		s += "." + p.Column
+	}
+	s += ": " + p.Kind
+	if p.Detail != "" {
+		s += ": " + p.Detail
+	}
+	return s
+}
+
+// SchemaReport is the result of SQLUtil.VerifySchema.
+type SchemaReport struct {
+	Tables   []string
+	Problems []SchemaProblem
+}
+
+func (r SchemaReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// Err returns an error joining all problems, or nil if there is none.
+func (r SchemaReport) Err() error {
+	errs := make([]error, len(r.Problems))
+	for i, p := range r.Problems {
+		errs[i] = errors.New(p.String())
+	}
+	return errors.Join(errs...)
+}
+
+// VerifySchema checks tables, or registered tables if none is given, against
+// the live schema read by q. It reports missing tables and columns, type and
+// nullability mismatches, and NOT NULL columns without default which inserts
+// don't write.
+func (s *SQLUtil) VerifySchema(ctx context.Context, q Queryer, tables ...Table) (SchemaReport, error) {
+	inspector, err := s.schemaInspector()
+	if err != nil {
+		return SchemaReport{}, err
+	}
+	if len(tables) == 0 {
+		tables = s.parser.Tables()
+	}
+	var report SchemaReport
+	for _, table := range tables {
+		report.Tables = append(report.Tables, table.Name)
+		live, err := inspector.InspectTable(ctx, q, table.Name)
+		if errors.Is(err, ErrNoTable) {
+			report.Problems = append(report.Problems, SchemaProblem{Kind: ProblemMissingTable, Table: table.Name})
+			continue
+		}
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", table.Name, err)
+		}
+		problems, err := s.verifyTable(table, live)
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", table.Name, err)
+		}
+		report.Problems = append(report.Problems, problems...)
+	}
+	return report, nil
+}
+
+func (s *SQLUtil) verifyTable(table Table, live TableSchema) ([]SchemaProblem, error) {
+	var problems []SchemaProblem
+	add := func(kind, col, detail string) {
+		problems = append(problems, SchemaProblem{Kind: kind, Table: table.Name, Column: col, Detail: detail})
+	}
+	required := func(col Column) bool {
+		return col.Notnull && !col.Default && !col.AutoIncr && col.Generated == ""
+	}
+	for _, col := range table.Cols {
+		liveCol, has := live.Col(col.Name)
+		if !has {
+			add(ProblemMissingColumn, col.Name, "")
+			continue
+		}
+		dbTyp, _, err := s.columnType(col)
+		if err != nil {
+			return nil, err
+		}
+		if !s.sameType(dbTyp, liveCol.DBType) {
+			add(ProblemTypeMismatch, col.Name, fmt.Sprintf("model %s, database %s", dbTyp, liveCol.DBType))
+		}
+		if col.Notnull != liveCol.Notnull && !liveCol.Primary {
+			if col.Notnull {
+				add(ProblemNullMismatch, col.Name, "model NOT NULL, database nullable")
+			} else {
+				add(ProblemNullMismatch, col.Name, "model nullable, database NOT NULL")
+			}
+		}
+		if !col.Insertable() && required(liveCol) {
+			add(ProblemRequiredColumn, col.Name, "not inserted by model")
+		}
+	}
+	for _, liveCol := range live.Cols {
+		if _, has := table.Col(liveCol.Name); !has && required(liveCol) {
+			add(ProblemRequiredColumn, liveCol.Name, "not defined by model")
+		}
+	}
+	return problems, nil
+}
@@ -0,0 +1,69 @@
+package sqldb
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestVerifySchema(t *testing.T) {
+	type Item struct {
+		Id    int64  `sqldb:"pk"`
+		Name  string `sqldb:"notnull"`
+		Price int64
+		Note  *string
+		Total int64 `sqldb:"readonly notnull"`
+		Owner *int64
+	}
+	type Missing struct {
+		Id int64 `sqldb:"pk"`
+	}
+	ctx := context.Background()
+	db := openSQLite3(t)
+	_, err := db.Exec(`CREATE TABLE item (
+		id INTEGER PRIMARY KEY,
+		name TEXT,
+		price TEXT,
+		total INTEGER NOT NULL,
+		owner INTEGER,
+		secret INTEGER NOT NULL,
+		extra INTEGER NOT NULL DEFAULT 0
+	)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser := NewTableParser()
+	if err = parser.Register(Item{}, Missing{}); err != nil {
+		t.Fatal(err)
+	}
+	su := NewSQLUtil(parser, SQLite3{})
+	report, err := su.VerifySchema(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var problems []string
+	for _, p := range report.Problems {
+		problems = append(problems, p.String())
+	}
+	expect := []string{
+		"item.name: nullability mismatch: model NOT NULL, database nullable",
+		"item.price: type mismatch: model INTEGER, database TEXT",
+		"item.note: missing column",
+		"item.total: required column: not inserted by model",
+		"item.secret: required column: not defined by model",
+		"missing: missing table",
+	}
+	if report.OK() || strings.Join(problems, "\n") != strings.Join(expect, "\n") {
+		t.Fatalf("unexpected problems:\n%s", strings.Join(problems, "\n"))
+	}
+	if err = report.Err(); err == nil || !strings.Contains(err.Error(), "missing: missing table") {
+		t.Fatal("unexpected report error", err)
+	}
+
+	item, _ := parser.StructTable(Item{})
+	item.Cols = item.Cols[:1]
+	report, err = su.VerifySchema(ctx, db, item)
+	if err != nil || len(report.Problems) != 2 || report.Problems[0].Column != "total" || report.Problems[1].Column != "secret" {
+		t.Fatal("unexpected report", report, err)
+	}
+}